@@ -10,9 +10,9 @@ import (
 	"github.com/konveyor/controller/pkg/logging"
 	"github.com/konveyor/tackle-hub/settings"
 	"github.com/konveyor/tackle-hub/task"
-	"net/http"
 	"os"
 	"strings"
+	"time"
 )
 
 var (
@@ -117,10 +117,11 @@ func newAdapter() (adapter *Adapter) {
 	}
 	//
 	// Build REST client.
-	client := &Client{
-		baseURL: Settings.Addon.Hub.URL,
-		http:    &http.Client{},
-	}
+	client := newClient(
+		Settings.Addon.Hub.URL,
+		secret.Hub.Token,
+		time.Duration(Settings.Addon.Hub.Timeout)*time.Second,
+		Settings.Addon.Hub.Retries)
 	//
 	// Build Adapter.
 	adapter = &Adapter{
@@ -3,6 +3,9 @@ package addon
 import (
 	"errors"
 	"github.com/konveyor/tackle-hub/api"
+	"github.com/konveyor/tackle-hub/bucket"
+	"io"
+	"net/http"
 	"os"
 	pathlib "path"
 )
@@ -53,7 +56,7 @@ func (h *Bucket) Ensure(appId uint, name string) (r *api.Bucket, err error) {
 // Get a bucket by ID.
 func (h *Bucket) Get(id uint) (r *api.Bucket, err error) {
 	r = &api.Bucket{}
-	path := Params{api.ID: id}.inject(api.BucketsRoot)
+	path := Params{api.ID: id}.inject(api.BucketRoot)
 	err = h.client.Get(path, r)
 	return
 }
@@ -69,7 +72,7 @@ func (h *Bucket) List() (list []api.Bucket, err error) {
 //
 // Delete an bucket.
 func (h *Bucket) Delete(r *api.Bucket) (err error) {
-	path := Params{api.ID: r.ID}.inject(api.BucketsRoot)
+	path := Params{api.ID: r.ID}.inject(api.BucketRoot)
 	err = h.client.Delete(path)
 	if err == nil {
 		Log.Info(
@@ -82,17 +85,168 @@ func (h *Bucket) Delete(r *api.Bucket) (err error) {
 
 //
 // Purge bucket.
+// The content is deleted.
 func (h *Bucket) Purge(r *api.Bucket) (err error) {
-	dir, err := os.ReadDir(r.Path)
+	err = h.Content(r).Delete("/")
+	return
+}
+
+//
+// Content returns the content API for the bucket.
+func (h *Bucket) Content(r *api.Bucket) (content *BucketContent) {
+	content = &BucketContent{
+		client: h.client,
+		bucket: r.ID,
+	}
+	return
+}
+
+//
+// BucketContent API.
+// Bucket content is transferred through the hub so
+// no bucket (volume) mount is needed.
+type BucketContent struct {
+	// hub API client.
+	client *Client
+	// bucket ID.
+	bucket uint
+}
+
+//
+// Put uploads a file or directory (source) to the
+// bucket path (destination). The content of a directory
+// (destination) is replaced.
+func (h *BucketContent) Put(source, destination string) (err error) {
+	st, err := os.Stat(source)
 	if err != nil {
 		return
 	}
-	for _, p := range dir {
-		path := pathlib.Join(r.Path, p.Name())
-		err = os.RemoveAll(path)
+	header := http.Header{}
+	header.Set("Content-Type", api.MIMEOctetStream)
+	if st.IsDir() {
+		var archive string
+		archive, err = h.archive(source)
 		if err != nil {
 			return
 		}
+		defer func() {
+			_ = os.Remove(archive)
+		}()
+		source = archive
+		header.Set("Content-Type", api.MIMETarGz)
+		header.Set(api.Directory, api.DirectoryExpand)
+	}
+	err = h.client.Upload(h.path(destination), header, source)
+	if err == nil {
+		Log.Info(
+			"Addon uploaded: bucket content.",
+			"bucket",
+			h.bucket,
+			"source",
+			source,
+			"destination",
+			destination)
+	}
+	return
+}
+
+//
+// Get downloads a file or directory at the bucket
+// path (source) to the destination.
+func (h *BucketContent) Get(source, destination string) (err error) {
+	header := http.Header{}
+	header.Set(api.Directory, api.DirectoryArchive)
+	err = h.client.Download(
+		h.path(source),
+		header,
+		func(header http.Header, body io.Reader) (err error) {
+			if header.Get(api.Directory) == api.DirectoryArchive {
+				err = os.RemoveAll(destination)
+				if err != nil {
+					return
+				}
+				err = os.MkdirAll(destination, 0777)
+				if err != nil {
+					return
+				}
+				err = bucket.Expand(&bucket.FileSystem{}, destination, "/", body)
+				return
+			}
+			err = os.MkdirAll(pathlib.Dir(destination), 0777)
+			if err != nil {
+				return
+			}
+			file, err := os.Create(destination)
+			if err != nil {
+				return
+			}
+			defer func() {
+				_ = file.Close()
+			}()
+			_, err = io.Copy(file, body)
+			return
+		})
+	if err == nil {
+		Log.Info(
+			"Addon downloaded: bucket content.",
+			"bucket",
+			h.bucket,
+			"source",
+			source,
+			"destination",
+			destination)
 	}
 	return
 }
+
+//
+// List the directory at the bucket path.
+func (h *BucketContent) List(path string) (list []api.BucketEntry, err error) {
+	list = []api.BucketEntry{}
+	err = h.client.Get(h.path(path), &list)
+	return
+}
+
+//
+// Delete the file or directory at the bucket path.
+// When the path is the bucket root (/), the content
+// is deleted.
+func (h *BucketContent) Delete(path string) (err error) {
+	err = h.client.Delete(h.path(path))
+	if err == nil {
+		Log.Info(
+			"Addon deleted: bucket content.",
+			"bucket",
+			h.bucket,
+			"path",
+			path)
+	}
+	return
+}
+
+//
+// path returns the content path (url).
+func (h *BucketContent) path(path string) (s string) {
+	s = Params{api.ID: h.bucket}.inject(api.BucketContent)
+	s = pathlib.Dir(s) + pathlib.Clean("/"+path)
+	return
+}
+
+//
+// archive writes a (gzip) tar archive of the directory
+// to a temporary file.
+func (h *BucketContent) archive(dir string) (path string, err error) {
+	file, err := os.CreateTemp("", "bucket-*.tar.gz")
+	if err != nil {
+		return
+	}
+	path = file.Name()
+	defer func() {
+		_ = file.Close()
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+	err = bucket.Archive(&bucket.FileSystem{}, dir, "/", file)
+	return
+}
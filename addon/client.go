@@ -6,47 +6,86 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"time"
+)
+
+//
+// Backoff (retry) settings.
+const (
+	// BackoffMin is the delay before the first retry.
+	BackoffMin = time.Second
+	// BackoffMax is the max delay between retries.
+	BackoffMax = 30 * time.Second
 )
 
 //
 // Client provides a REST client.
+// Requests that fail with a connection error or a
+// (transient) 502, 503 or 504 are retried with backoff.
+// POST is only retried when the connection could not
+// be established, since the hub may have processed it.
 type Client struct {
 	// baseURL for the nub.
 	baseURL string
 	// http client.
 	http *http.Client
+	// stream http client used to transfer content.
+	// No overall deadline since the transfer time depends
+	// on the size of the content.
+	stream *http.Client
+	// token (bearer) for the task.
+	token string
+	// retries is the max number of retries.
+	retries int
+}
+
+//
+// newClient returns a new client.
+// The timeout limits connecting, the TLS handshake and waiting
+// for the reply header. It also limits the whole (API) request
+// except for content uploads and downloads.
+func newClient(baseURL, token string, timeout time.Duration, retries int) (client *Client) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	client = &Client{
+		baseURL: baseURL,
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+		stream: &http.Client{
+			Transport: transport,
+		},
+		token:   token,
+		retries: retries,
+	}
+	return
 }
 
 //
 // Get a resource.
 func (r *Client) Get(path string, object interface{}) (err error) {
-	request := &http.Request{
-		Method: http.MethodGet,
-		URL:    r.join(path),
-	}
-	reply, err := r.http.Do(request)
+	reply, err := r.send(http.MethodGet, path, nil)
 	if err != nil {
 		return
 	}
-	defer func() {
-		_ = reply.Body.Close()
-	}()
-	status := reply.StatusCode
-	switch status {
+	switch reply.status {
 	case http.StatusOK:
-		var body []byte
-		body, err = io.ReadAll(reply.Body)
-		if err != nil {
-			return
-		}
-		err = json.Unmarshal(body, object)
-	case http.StatusNotFound:
-		err = &NotFound{path}
+		err = json.Unmarshal(reply.body, object)
 	default:
-		err = errors.New(http.StatusText(status))
+		err = reply.error()
 	}
 
 	return
@@ -59,33 +98,16 @@ func (r *Client) Post(path string, object interface{}) (err error) {
 	if err != nil {
 		return
 	}
-	reader := bytes.NewReader(bfr)
-	request := &http.Request{
-		Method: http.MethodPost,
-		Body:   ioutil.NopCloser(reader),
-		URL:    r.join(path),
-	}
-	reply, err := r.http.Do(request)
+	reply, err := r.send(http.MethodPost, path, bfr)
 	if err != nil {
 		return
 	}
-	status := reply.StatusCode
-	switch status {
+	switch reply.status {
 	case http.StatusOK,
 		http.StatusCreated:
-		var body []byte
-		body, err = ioutil.ReadAll(reply.Body)
-		if err != nil {
-			return
-		}
-		err = json.Unmarshal(body, object)
-		if err != nil {
-			return
-		}
-	case http.StatusConflict:
-		err = &Conflict{path}
+		err = json.Unmarshal(reply.body, object)
 	default:
-		err = errors.New(http.StatusText(status))
+		err = reply.error()
 	}
 
 	return
@@ -98,33 +120,16 @@ func (r *Client) Put(path string, object interface{}) (err error) {
 	if err != nil {
 		return
 	}
-	reader := bytes.NewReader(bfr)
-	request := &http.Request{
-		Method: http.MethodPut,
-		Body:   ioutil.NopCloser(reader),
-		URL:    r.join(path),
-	}
-	reply, err := r.http.Do(request)
+	reply, err := r.send(http.MethodPut, path, bfr)
 	if err != nil {
 		return
 	}
-	status := reply.StatusCode
-	switch status {
+	switch reply.status {
 	case http.StatusNoContent:
 	case http.StatusOK:
-		var body []byte
-		body, err = ioutil.ReadAll(reply.Body)
-		if err != nil {
-			return
-		}
-		err = json.Unmarshal(body, object)
-		if err != nil {
-			return
-		}
-	case http.StatusNotFound:
-		err = &NotFound{path}
+		err = json.Unmarshal(reply.body, object)
 	default:
-		err = errors.New(http.StatusText(status))
+		err = reply.error()
 	}
 
 	return
@@ -133,44 +138,314 @@ func (r *Client) Put(path string, object interface{}) (err error) {
 //
 // Delete a resource.
 func (r *Client) Delete(path string) (err error) {
-	request := &http.Request{
-		Method: http.MethodDelete,
-		URL:    r.join(path),
+	reply, err := r.send(http.MethodDelete, path, nil)
+	if err != nil {
+		return
+	}
+	switch reply.status {
+	case http.StatusOK,
+		http.StatusNoContent:
+	default:
+		err = reply.error()
+	}
+
+	return
+}
+
+//
+// Upload (PUT) the file content.
+func (r *Client) Upload(path string, header http.Header, source string) (err error) {
+	request := &request{
+		method: http.MethodPut,
+		path:   path,
+		header: header,
+		stream: true,
+		body: func() (io.ReadCloser, error) {
+			return os.Open(source)
+		},
 	}
-	reply, err := r.http.Do(request)
+	reply, err := r.do(request)
 	if err != nil {
 		return
 	}
-	defer func() {
-		_ = reply.Body.Close()
-	}()
-	status := reply.StatusCode
-	switch status {
+	switch reply.status {
 	case http.StatusOK,
 		http.StatusNoContent:
-	case http.StatusNotFound:
-		err = &NotFound{path}
 	default:
-		err = errors.New(http.StatusText(status))
+		err = reply.error()
+	}
+
+	return
+}
+
+//
+// Download (GET) content.
+// The output function is called with the (200) reply header and
+// body. It may be called more than once (retries).
+func (r *Client) Download(path string, header http.Header, output Output) (err error) {
+	request := &request{
+		method: http.MethodGet,
+		path:   path,
+		header: header,
+		output: output,
+		stream: true,
+	}
+	reply, err := r.do(request)
+	if err != nil {
+		return
+	}
+	switch reply.status {
+	case http.StatusOK:
+	default:
+		err = reply.error()
+	}
+
+	return
+}
+
+//
+// send the request (with retries) and read the reply.
+func (r *Client) send(method, path string, body []byte) (reply *response, err error) {
+	request := &request{
+		method: method,
+		path:   path,
+		header: http.Header{},
+	}
+	if body != nil {
+		request.header.Set("Content-Type", "application/json")
+		request.body = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+	reply, err = r.do(request)
+	return
+}
+
+//
+// do sends the request (with retries) and reads the reply.
+// The reply body is always read and closed.
+func (r *Client) do(request *request) (reply *response, err error) {
+	delay := BackoffMin
+	for attempt := 0; ; attempt++ {
+		reply, err = r.doOnce(request)
+		if attempt >= r.retries || !r.retry(request.method, reply, err) {
+			break
+		}
+		Log.Info(
+			"Hub request failed, retrying.",
+			"method",
+			request.method,
+			"path",
+			request.path,
+			"attempt",
+			attempt+1,
+			"delay",
+			delay.String())
+		time.Sleep(delay)
+		delay *= 2
+		if delay > BackoffMax {
+			delay = BackoffMax
+		}
 	}
 
 	return
 }
 
+//
+// doOnce sends the request and reads the reply.
+// The (200) reply body is passed to the output function
+// when specified.
+func (r *Client) doOnce(request *request) (reply *response, err error) {
+	httpRequest := &http.Request{
+		Method: request.method,
+		URL:    r.join(request.path),
+		Header: r.header(),
+	}
+	for name, values := range request.header {
+		httpRequest.Header[name] = values
+	}
+	if request.body != nil {
+		var body io.ReadCloser
+		body, err = request.body()
+		if err != nil {
+			return
+		}
+		httpRequest.Body = body
+		if f, cast := body.(*os.File); cast {
+			st, stErr := f.Stat()
+			if stErr == nil {
+				httpRequest.ContentLength = st.Size()
+			}
+		}
+	}
+	client := r.http
+	if request.stream {
+		client = r.stream
+	}
+	httpReply, err := client.Do(httpRequest)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = httpReply.Body.Close()
+	}()
+	reply = &response{
+		method: request.method,
+		path:   request.path,
+		status: httpReply.StatusCode,
+	}
+	if request.output != nil && reply.status == http.StatusOK {
+		err = request.output(httpReply.Header, httpReply.Body)
+		if err != nil {
+			reply = nil
+		}
+		return
+	}
+	reply.body, err = io.ReadAll(httpReply.Body)
+	return
+}
+
+//
+// retry returns true when the request should be retried.
+func (r *Client) retry(method string, reply *response, err error) (retry bool) {
+	if err != nil {
+		if method != http.MethodPost {
+			retry = true
+			return
+		}
+		opErr := &net.OpError{}
+		if errors.As(err, &opErr) {
+			retry = opErr.Op == "dial"
+		}
+		return
+	}
+	switch reply.status {
+	case http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		retry = method != http.MethodPost
+	}
+
+	return
+}
+
+//
+// header returns the request header.
+// The task token is passed (when set).
+func (r *Client) header() (header http.Header) {
+	header = http.Header{}
+	if r.token != "" {
+		header.Set("Authorization", "Bearer "+r.token)
+	}
+	return
+}
+
 func (r *Client) join(path string) (parsedURL *url.URL) {
 	parsedURL, _ = url.Parse(r.baseURL)
 	parsedURL.Path = path
 	return
 }
 
+//
+// Output function used to read (download) content.
+type Output func(header http.Header, body io.Reader) error
+
+//
+// request to be sent to the hub.
+type request struct {
+	method string
+	path   string
+	// header (additional).
+	header http.Header
+	// body opened for each attempt (optional).
+	body func() (io.ReadCloser, error)
+	// output reads the (200) reply (optional).
+	output Output
+	// stream content (no overall deadline).
+	stream bool
+}
+
+//
+// response read from the hub.
+type response struct {
+	method string
+	path   string
+	status int
+	body   []byte
+}
+
+//
+// error returns the error for the (unexpected) status.
+func (r *response) error() (err error) {
+	switch r.status {
+	case http.StatusNotFound:
+		err = &NotFound{
+			Method: r.method,
+			Path:   r.path,
+		}
+	case http.StatusConflict:
+		err = &Conflict{
+			Method: r.method,
+			Path:   r.path,
+		}
+	default:
+		hubErr := &HubError{
+			Method: r.method,
+			Path:   r.path,
+			Status: r.status,
+		}
+		content := struct {
+			Error string `json:"error"`
+		}{}
+		if json.Unmarshal(r.body, &content) == nil {
+			hubErr.Message = content.Error
+		}
+		err = hubErr
+	}
+
+	return
+}
+
+//
+// HubError reports an unexpected hub response.
+type HubError struct {
+	// Method (http).
+	Method string
+	// Path (url).
+	Path string
+	// Status (http).
+	Status int
+	// Message reported by the hub.
+	Message string
+}
+
+func (e HubError) Error() (s string) {
+	s = fmt.Sprintf(
+		"%s: path:%s [%d %s]",
+		e.Method,
+		e.Path,
+		e.Status,
+		http.StatusText(e.Status))
+	if e.Message != "" {
+		s += " " + e.Message
+	}
+	return
+}
+
+func (e *HubError) Is(err error) (matched bool) {
+	_, matched = err.(*HubError)
+	return
+}
+
 //
 // Conflict reports 409 error.
 type Conflict struct {
-	Path string
+	Method string
+	Path   string
 }
 
 func (e Conflict) Error() string {
-	return fmt.Sprintf("POST: path:%s [conflict]", e.Path)
+	return fmt.Sprintf("%s: path:%s [conflict]", e.Method, e.Path)
 }
 
 func (e *Conflict) Is(err error) (matched bool) {
@@ -181,11 +456,12 @@ func (e *Conflict) Is(err error) (matched bool) {
 //
 // NotFound reports 404 error.
 type NotFound struct {
-	Path string
+	Method string
+	Path   string
 }
 
 func (e NotFound) Error() string {
-	return fmt.Sprintf("GET: path:%s [not-found]", e.Path)
+	return fmt.Sprintf("%s: path:%s [not-found]", e.Method, e.Path)
 }
 
 func (e *NotFound) Is(err error) (matched bool) {
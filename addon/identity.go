@@ -13,35 +13,25 @@ type Identity struct {
 
 //
 // Get an identity by ID.
+// The identity (decrypted) must belong to an application
+// named in the task data.
 func (h *Identity) Get(id uint) (r *api.Identity, err error) {
 	r = &api.Identity{}
-	path := Params{api.ID: id}.inject(api.IdentityRoot)
-	err = h.client.Get(path, r)
-	if err != nil {
-		return
+	params := Params{
+		api.ID:  Addon.secret.Hub.Task,
+		api.Key: id,
 	}
-	m := r.Model()
-	err = m.Decrypt(Addon.secret.Hub.Encryption.Passphrase)
-	r.With(m)
+	path := params.inject(api.TaskIdentityRoot)
+	err = h.client.Get(path, r)
 	return
 }
 
 //
-// List identities.
+// List (decrypted) identities for the applications
+// named in the task data.
 func (h *Identity) List() (list []api.Identity, err error) {
 	list = []api.Identity{}
-	err = h.client.Get(api.IdentitiesRoot, &list)
-	if err != nil {
-		return
-	}
-	for i := range list {
-		r := &list[i]
-		m := r.Model()
-		err = m.Decrypt(Addon.secret.Hub.Encryption.Passphrase)
-		r.With(m)
-		if err != nil {
-			return
-		}
-	}
+	path := Params{api.ID: Addon.secret.Hub.Task}.inject(api.TaskIdentitiesRoot)
+	err = h.client.Get(path, &list)
 	return
 }
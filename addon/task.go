@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/konveyor/tackle-hub/api"
 	"github.com/konveyor/tackle-hub/task"
+	"time"
 )
 
 //
@@ -71,15 +72,88 @@ func (h *Task) Failed(reason string, x ...interface{}) {
 // Activity report addon activity.
 // The description can be a printf style format.
 func (h *Task) Activity(entry string, x ...interface{}) {
-	entry = fmt.Sprintf(entry, x...)
-	h.report.Activity = append(
-		h.report.Activity,
-		entry)
+	h.ActivityWith(api.SeverityInfo, nil, entry, x...)
+	return
+}
+
+//
+// Warning report addon activity (warning).
+// The description can be a printf style format.
+func (h *Task) Warning(entry string, x ...interface{}) {
+	h.ActivityWith(api.SeverityWarning, nil, entry, x...)
+	return
+}
+
+//
+// Errorf report an addon error.
+// The error is listed in the report errors and activity.
+// Unlike Failed(), the task is not failed.
+// The description can be a printf style format.
+func (h *Task) Errorf(entry string, x ...interface{}) {
+	mark := time.Now()
+	h.report.Errors = append(
+		h.report.Errors,
+		api.TaskReportEntry{
+			Time:     &mark,
+			Severity: api.SeverityError,
+			Message:  fmt.Sprintf(entry, x...),
+		})
+	h.ActivityWith(api.SeverityError, nil, entry, x...)
+	return
+}
+
+//
+// ActivityWith report addon activity with severity
+// and fields (optional).
+// The description can be a printf style format.
+func (h *Task) ActivityWith(severity string, fields map[string]interface{}, entry string, x ...interface{}) {
+	mark := time.Now()
+	reported := api.TaskReportEntry{
+		Time:     &mark,
+		Severity: severity,
+		Message:  fmt.Sprintf(entry, x...),
+		Fields:   fields,
+	}
+	h.report.Entries = append(
+		h.report.Entries,
+		reported)
 	h.pushReport()
 	Log.Info(
 		"Addon reported: activity.",
+		"severity",
+		reported.Severity,
 		"activity",
-		h.report.Activity)
+		reported.Message)
+	return
+}
+
+//
+// Output report an addon output.
+// Outputs may be passed as inputs to other tasks.
+// Example: the ID of a bucket created by the addon.
+func (h *Task) Output(key string, value interface{}) {
+	if h.report.Output == nil {
+		h.report.Output = make(map[string]interface{})
+	}
+	h.report.Output[key] = value
+	h.pushReport()
+	Log.Info(
+		"Addon reported: output.",
+		"key",
+		key,
+		"value",
+		value)
+	return
+}
+
+//
+// Result report the addon result.
+// The result is a (structured) document with a schema
+// defined by the addon. Example: the number of issues found.
+func (h *Task) Result(object interface{}) {
+	h.report.Result = object
+	h.pushReport()
+	Log.Info("Addon reported: result.")
 	return
 }
 
@@ -118,20 +192,23 @@ func (h *Task) Completed(n int) {
 
 //
 // pushReport create/update the task report.
+// The report is updated (PUT) first since updates are idempotent
+// and retried by the client. It is created (POST) when not found.
+// Errors are logged rather than failing the addon.
 func (h *Task) pushReport() {
-	var err error
-	defer func() {
-		if err != nil {
-			panic(err)
-		}
-	}()
 	params := Params{
 		api.ID: h.secret.Hub.Task,
 	}
 	path := params.inject(api.TaskReportRoot)
-	err = h.client.Post(path, &h.report)
-	if errors.Is(err, &Conflict{}) {
-		err = h.client.Put(path, &h.report)
+	err := h.client.Put(path, &h.report)
+	if errors.Is(err, &NotFound{}) {
+		err = h.client.Post(path, &h.report)
+		if errors.Is(err, &Conflict{}) {
+			err = h.client.Put(path, &h.report)
+		}
+	}
+	if err != nil {
+		Log.Error(err, "Report task failed.")
 	}
 
 	return
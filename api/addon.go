@@ -39,6 +39,9 @@ func (h AddonHandler) AddRoutes(e *gin.Engine) {
 // @router /addons/{name} [get]
 // @param name path string true "Addon name"
 func (h AddonHandler) Get(ctx *gin.Context) {
+	if h.clusterMissing(ctx) {
+		return
+	}
 	name := ctx.Param(Name)
 	addon := &crd.Addon{}
 	err := h.Client.Get(
@@ -71,6 +74,9 @@ func (h AddonHandler) Get(ctx *gin.Context) {
 // @success 200 {object} []api.Addon
 // @router /addons [get]
 func (h AddonHandler) List(ctx *gin.Context) {
+	if h.clusterMissing(ctx) {
+		return
+	}
 	list := &crd.AddonList{}
 	err := h.Client.List(
 		context.TODO(),
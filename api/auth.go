@@ -0,0 +1,201 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle-hub/model"
+	"github.com/konveyor/tackle-hub/task"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//
+// TaskAuth authenticates addon requests using the
+// (bearer) task token. The token is required to update the
+// task report, to get (decrypted) identities and to write
+// bucket content. These routes are only used by addons.
+// A token is limited to the routes used by addons, its own
+// task and the applications named in the task data.
+// Other requests without a token are not restricted.
+type TaskAuth struct {
+	// DB
+	DB *gorm.DB
+}
+
+//
+// Handler (middleware).
+func (r *TaskAuth) Handler(ctx *gin.Context) {
+	token := r.token(ctx)
+	if token == "" {
+		if r.required(ctx) {
+			r.unauthorized(ctx)
+		}
+		return
+	}
+	m := &model.Task{}
+	result := r.DB.Limit(1).Find(m, "token = ?", task.HashToken(token))
+	if result.Error != nil {
+		ctx.AbortWithStatusJSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"error": result.Error.Error(),
+			})
+		return
+	}
+	if result.RowsAffected == 0 || !task.IsActive(m.Status) {
+		r.unauthorized(ctx)
+		return
+	}
+	if !r.permitted(ctx, m) {
+		ctx.AbortWithStatusJSON(
+			http.StatusForbidden,
+			gin.H{
+				"error": "not permitted by task token.",
+			})
+		return
+	}
+}
+
+//
+// required returns true when the route requires a token.
+func (r *TaskAuth) required(ctx *gin.Context) (required bool) {
+	switch ctx.FullPath() {
+	case TaskReportRoot,
+		TaskIdentitiesRoot,
+		TaskIdentitiesRoot + "/",
+		TaskIdentityRoot:
+		required = true
+	case BucketContent,
+		AppBucketContentRoot:
+		required = ctx.Request.Method != http.MethodGet &&
+			ctx.Request.Method != http.MethodHead
+	}
+
+	return
+}
+
+//
+// permitted returns true when the request is within
+// the scope of the task. Only the routes used by addons
+// are permitted.
+func (r *TaskAuth) permitted(ctx *gin.Context, m *model.Task) (permitted bool) {
+	id := ctx.Param(ID)
+	read := ctx.Request.Method == http.MethodGet ||
+		ctx.Request.Method == http.MethodHead
+	switch ctx.FullPath() {
+	case TaskRoot,
+		TaskResultRoot,
+		TaskIdentitiesRoot,
+		TaskIdentitiesRoot + "/",
+		TaskIdentityRoot:
+		permitted = read && id == strconv.Itoa(int(m.ID))
+	case TaskReportRoot:
+		permitted = id == strconv.Itoa(int(m.ID))
+	case ApplicationRoot:
+		permitted = (read || ctx.Request.Method == http.MethodPut) &&
+			r.application(m, id)
+	case AppBucketsRoot,
+		AppBucketRoot,
+		AppBucketRoot + "/",
+		AppBucketContentRoot:
+		permitted = r.application(m, id)
+	case BucketsRoot:
+		permitted = ctx.Request.Method == http.MethodPost &&
+			r.application(m, r.bucketApplication(ctx))
+	case BucketRoot,
+		BucketContent:
+		bucket := &model.Bucket{}
+		result := r.DB.Limit(1).Find(bucket, id)
+		if result.Error == nil && result.RowsAffected > 0 {
+			permitted = r.application(m, strconv.Itoa(int(bucket.ApplicationID)))
+		}
+	case TagsRoot,
+		TagsRoot + "/",
+		TagRoot,
+		TagTypesRoot,
+		TagTypesRoot + "/",
+		TagTypeRoot:
+		permitted = true
+	case ProxiesRoot,
+		ProxiesRoot + "/",
+		ProxyRoot,
+		SettingsRoot,
+		SettingsRoot + "/",
+		SettingRoot:
+		permitted = read
+	}
+
+	return
+}
+
+//
+// bucketApplication returns the application (ID) in the
+// (bucket) request body. The body is restored for the handler.
+func (r *TaskAuth) bucketApplication(ctx *gin.Context) (id string) {
+	body, err := ctx.GetRawData()
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	resource := &Bucket{}
+	err = json.Unmarshal(body, resource)
+	if err == nil {
+		id = strconv.Itoa(int(resource.ApplicationID))
+	}
+	return
+}
+
+//
+// application returns true when the application (ID)
+// is named in the task data.
+func (r *TaskAuth) application(m *model.Task, id string) (named bool) {
+	for _, appId := range taskApplications(m) {
+		if id == strconv.Itoa(int(appId)) {
+			named = true
+			break
+		}
+	}
+	return
+}
+
+//
+// token returns the bearer token.
+func (r *TaskAuth) token(ctx *gin.Context) (token string) {
+	header := ctx.GetHeader("Authorization")
+	fields := strings.Fields(header)
+	if len(fields) == 2 && strings.EqualFold(fields[0], "Bearer") {
+		token = fields[1]
+	}
+	return
+}
+
+//
+// unauthorized aborts the request (401).
+func (r *TaskAuth) unauthorized(ctx *gin.Context) {
+	ctx.Header("WWW-Authenticate", "Bearer")
+	ctx.AbortWithStatusJSON(
+		http.StatusUnauthorized,
+		gin.H{
+			"error": "task token not valid.",
+		})
+}
+
+//
+// taskApplications returns the IDs of the applications
+// named in the task data: application=ID or applications=[ID].
+func taskApplications(m *model.Task) (ids []uint) {
+	data := struct {
+		Application  *uint  `json:"application"`
+		Applications []uint `json:"applications"`
+	}{}
+	_ = json.Unmarshal(m.Data, &data)
+	if data.Application != nil {
+		ids = append(ids, *data.Application)
+	}
+	ids = append(ids, data.Applications...)
+	return
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle-hub/model"
+	"github.com/konveyor/tackle-hub/task"
+	"github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTaskAuth(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db := testDB(t)
+	for _, name := range []string{"a", "b"} {
+		g.Expect(db.Create(&model.Application{Name: name}).Error).To(gomega.BeNil())
+	}
+	for _, m := range []*model.Task{
+		{
+			Name:   "running",
+			Addon:  "test",
+			Status: task.Running,
+			Token:  task.HashToken("running"),
+			Data:   []byte(`{"application":1}`),
+		},
+		{
+			Name:   "succeeded",
+			Addon:  "test",
+			Status: task.Succeeded,
+			Token:  task.HashToken("succeeded"),
+			Data:   []byte(`{"application":1}`),
+		},
+	} {
+		g.Expect(db.Create(m).Error).To(gomega.BeNil())
+	}
+	for _, id := range []uint{1, 2} {
+		bucket := &model.Bucket{Name: "test", ApplicationID: id}
+		g.Expect(db.Create(bucket).Error).To(gomega.BeNil())
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	auth := &TaskAuth{DB: db}
+	router.Use(auth.Handler)
+	for _, path := range []string{
+		TaskRoot,
+		TaskReportRoot,
+		TaskResultRoot,
+		TaskIdentitiesRoot,
+		TaskIdentityRoot,
+		ApplicationRoot,
+		AppBucketsRoot,
+		AppBucketRoot,
+		AppBucketContentRoot,
+		BucketsRoot,
+		BucketRoot,
+		BucketContent,
+		IdentitiesRoot,
+		TagsRoot,
+		TagRoot,
+		ProxiesRoot,
+		ProxyRoot,
+	} {
+		router.Any(path, func(ctx *gin.Context) {
+			ctx.Status(http.StatusOK)
+		})
+	}
+	send := func(token, method, path, body string) (status int) {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+		status = w.Code
+		return
+	}
+	cases := []struct {
+		token  string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		// Permitted.
+		{"running", "GET", "/tasks/1", "", http.StatusOK},
+		{"running", "GET", "/tasks/1/result", "", http.StatusOK},
+		{"running", "POST", "/tasks/1/report", "", http.StatusOK},
+		{"running", "PUT", "/tasks/1/report", "", http.StatusOK},
+		{"running", "GET", "/tasks/1/identities", "", http.StatusOK},
+		{"running", "GET", "/tasks/1/identities/source", "", http.StatusOK},
+		{"running", "GET", ApplicationsRoot + "/1", "", http.StatusOK},
+		{"running", "PUT", ApplicationsRoot + "/1", "", http.StatusOK},
+		{"running", "GET", ApplicationsRoot + "/1/buckets", "", http.StatusOK},
+		{"running", "POST", ApplicationsRoot + "/1/buckets", "", http.StatusOK},
+		{"running", "PUT", ApplicationsRoot + "/1/buckets/test/content/a", "", http.StatusOK},
+		{"running", "POST", "/buckets", `{"application":1}`, http.StatusOK},
+		{"running", "GET", "/buckets/1", "", http.StatusOK},
+		{"running", "PUT", "/buckets/1/content/a", "", http.StatusOK},
+		{"running", "GET", TagsRoot, "", http.StatusOK},
+		{"running", "POST", TagsRoot, "", http.StatusOK},
+		{"running", "GET", "/proxies/1", "", http.StatusOK},
+		// Not permitted: other task.
+		{"running", "GET", "/tasks/2", "", http.StatusForbidden},
+		{"running", "PUT", "/tasks/2/report", "", http.StatusForbidden},
+		{"running", "GET", "/tasks/2/identities", "", http.StatusForbidden},
+		// Not permitted: write own task.
+		{"running", "PUT", "/tasks/1", "", http.StatusForbidden},
+		{"running", "DELETE", "/tasks/1", "", http.StatusForbidden},
+		// Not permitted: other application.
+		{"running", "GET", ApplicationsRoot + "/2", "", http.StatusForbidden},
+		{"running", "DELETE", ApplicationsRoot + "/1", "", http.StatusForbidden},
+		{"running", "GET", ApplicationsRoot + "/2/buckets", "", http.StatusForbidden},
+		{"running", "PUT", ApplicationsRoot + "/2/buckets/test/content/a", "", http.StatusForbidden},
+		{"running", "POST", "/buckets", `{"application":2}`, http.StatusForbidden},
+		{"running", "GET", "/buckets", "", http.StatusForbidden},
+		{"running", "GET", "/buckets/2", "", http.StatusForbidden},
+		{"running", "GET", "/buckets/9", "", http.StatusForbidden},
+		{"running", "PUT", "/buckets/2/content/a", "", http.StatusForbidden},
+		// Not permitted: other routes and methods.
+		{"running", "GET", IdentitiesRoot, "", http.StatusForbidden},
+		{"running", "POST", ProxiesRoot, "", http.StatusForbidden},
+		// Token not valid.
+		{"other", "GET", "/tasks/1", "", http.StatusUnauthorized},
+		{"succeeded", "GET", "/tasks/2", "", http.StatusUnauthorized},
+		// Token required.
+		{"", "PUT", "/tasks/1/report", "", http.StatusUnauthorized},
+		{"", "GET", "/tasks/1/identities", "", http.StatusUnauthorized},
+		{"", "PUT", "/buckets/1/content/a", "", http.StatusUnauthorized},
+		{"", "DELETE", ApplicationsRoot + "/1/buckets/test/content/a", "", http.StatusUnauthorized},
+		// Token not required.
+		{"", "GET", "/tasks/2", "", http.StatusOK},
+		{"", "GET", IdentitiesRoot, "", http.StatusOK},
+		{"", "GET", "/buckets/1/content/a", "", http.StatusOK},
+	}
+	for _, c := range cases {
+		status := send(c.token, c.method, c.path, c.body)
+		g.Expect(status).To(
+			gomega.Equal(c.status),
+			c.token+" "+c.method+" "+c.path)
+	}
+}
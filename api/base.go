@@ -4,8 +4,10 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/konveyor/tackle-hub/model"
+	"github.com/konveyor/tackle-hub/task"
 	"github.com/mattn/go-sqlite3"
 	"gorm.io/gorm"
+	"k8s.io/client-go/kubernetes"
 	"net/http"
 	"os"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -20,12 +22,44 @@ type BaseHandler struct {
 	DB *gorm.DB
 	// k8s Client
 	Client client.Client
+	// k8s client set.
+	ClientSet kubernetes.Interface
 }
 
-// With database and k8s client.
-func (h *BaseHandler) With(db *gorm.DB, client client.Client) {
+// With database and k8s clients.
+func (h *BaseHandler) With(db *gorm.DB, client client.Client, clientSet kubernetes.Interface) {
 	h.DB = db.Debug()
 	h.Client = client
+	h.ClientSet = clientSet
+}
+
+//
+// clusterMissing writes 501 when the k8s client is not
+// available. Example: the (local) hub started by: hub addon run.
+func (h *BaseHandler) clusterMissing(ctx *gin.Context) (missing bool) {
+	if h.Client != nil {
+		return
+	}
+	missing = true
+	ctx.JSON(
+		http.StatusNotImplemented,
+		gin.H{
+			"error": "not supported without the cluster.",
+		})
+	return
+}
+
+//
+// created publishes task created notifications.
+func (h *BaseHandler) created(tasks ...*model.Task) {
+	for _, m := range tasks {
+		task.Notifier.Publish(
+			task.Notification{
+				Kind:   task.Created,
+				Task:   m.ID,
+				Status: m.Status,
+			})
+	}
 }
 
 //
@@ -222,3 +256,21 @@ func (r *Resource) With(m *model.Model) {
 	r.UpdateUser = m.UpdateUser
 	r.CreateTime = m.CreateTime
 }
+
+//
+// flushWriter flushes after each write.
+// Used to stream responses.
+type flushWriter struct {
+	writer gin.ResponseWriter
+}
+
+//
+// Write and flush.
+func (w *flushWriter) Write(b []byte) (n int, err error) {
+	n, err = w.writer.Write(b)
+	if err != nil {
+		return
+	}
+	w.writer.Flush()
+	return
+}
@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	crd "github.com/konveyor/tackle-hub/k8s/api/tackle/v1alpha1"
+	"github.com/konveyor/tackle-hub/model"
+	"github.com/konveyor/tackle-hub/task"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+)
+
+//
+// Routes
+const (
+	BatchesRoot     = "/batches"
+	BatchRoot       = BatchesRoot + "/:" + ID
+	BatchCancelRoot = BatchRoot + "/cancel"
+)
+
+//
+// BatchHandler handles task batch routes.
+type BatchHandler struct {
+	BaseHandler
+}
+
+//
+// AddRoutes adds routes.
+func (h BatchHandler) AddRoutes(e *gin.Engine) {
+	e.GET(BatchesRoot, h.List)
+	e.GET(BatchesRoot+"/", h.List)
+	e.POST(BatchesRoot, h.Create)
+	e.GET(BatchRoot, h.Get)
+	e.DELETE(BatchRoot, h.Delete)
+	e.PUT(BatchCancelRoot, h.Cancel)
+}
+
+// Get godoc
+// @summary Get a batch by ID.
+// @description Get a batch by ID.
+// @tags get
+// @produce json
+// @success 200 {object} api.Batch
+// @router /batches/{id} [get]
+// @param id path string true "Batch ID"
+func (h BatchHandler) Get(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	m := &model.Batch{}
+	db := h.DB.Preload("Tasks")
+	result := db.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	r := Batch{}
+	r.With(m)
+
+	ctx.JSON(http.StatusOK, r)
+}
+
+// List godoc
+// @summary List all batches.
+// @description List all batches.
+// @tags get
+// @produce json
+// @success 200 {object} []api.Batch
+// @router /batches [get]
+func (h BatchHandler) List(ctx *gin.Context) {
+	var list []model.Batch
+	pagination := NewPagination(ctx)
+	db := pagination.apply(h.DB)
+	db = db.Preload("Tasks")
+	result := db.Find(&list)
+	if result.Error != nil {
+		h.listFailed(ctx, result.Error)
+		return
+	}
+	resources := []Batch{}
+	for i := range list {
+		r := Batch{}
+		r.With(&list[i])
+		resources = append(resources, r)
+	}
+
+	ctx.JSON(http.StatusOK, resources)
+}
+
+// Create godoc
+// @summary Create a batch.
+// @description Create a batch.
+// @description A task is created for each application listed
+// @description and each application matched by the selector.
+// @description The application ID is set in the task data.
+// @tags create
+// @accept json
+// @produce json
+// @success 201 {object} api.Batch
+// @router /batches [post]
+// @param batch body api.Batch true "Batch data"
+func (h BatchHandler) Create(ctx *gin.Context) {
+	if h.clusterMissing(ctx) {
+		return
+	}
+	r := &Batch{}
+	err := ctx.BindJSON(r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	addon := &crd.Addon{}
+	err = h.Client.Get(
+		context.TODO(),
+		client.ObjectKey{
+			Namespace: Settings.Hub.Namespace,
+			Name:      r.Addon,
+		},
+		addon)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			err = fmt.Errorf("addon '%s' not found.", r.Addon)
+			h.bindFailed(ctx, err)
+		} else {
+			h.createFailed(ctx, err)
+		}
+		return
+	}
+	ids, err := h.applications(r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	m := r.Model()
+	err = h.DB.Transaction(func(tx *gorm.DB) (err error) {
+		result := tx.Create(m)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		tasks := []model.Task{}
+		for _, id := range ids {
+			tasks = append(tasks, r.task(m, addon, id))
+		}
+		result = tx.CreateInBatches(&tasks, 100)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		m.Tasks = tasks
+		return
+	})
+	if err != nil {
+		h.createFailed(ctx, err)
+		return
+	}
+	for i := range m.Tasks {
+		h.created(&m.Tasks[i])
+	}
+	r.With(m)
+
+	ctx.JSON(http.StatusCreated, r)
+}
+
+// Delete godoc
+// @summary Delete a batch.
+// @description Delete a batch.
+// @description The tasks are not deleted.
+// @tags delete
+// @success 204
+// @router /batches/{id} [delete]
+// @param id path string true "Batch ID"
+func (h BatchHandler) Delete(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	m := &model.Batch{}
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.deleteFailed(ctx, result.Error)
+		return
+	}
+	result = h.DB.Delete(m, id)
+	if result.Error != nil {
+		h.deleteFailed(ctx, result.Error)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Cancel godoc
+// @summary Cancel a batch.
+// @description Cancel a batch.
+// @description Each task that has not terminated is canceled.
+// @tags update
+// @success 202
+// @router /batches/{id}/cancel [put]
+// @param id path string true "Batch ID"
+func (h BatchHandler) Cancel(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	m := &model.Batch{}
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	db := h.DB.Model(&model.Task{})
+	db = db.Where("batchid", m.ID)
+	db = db.Where(
+		"status NOT IN ?",
+		[]string{
+			task.Succeeded,
+			task.Failed,
+			task.TimedOut,
+			task.Canceled,
+		})
+	result = db.Update("canceled", true)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+//
+// applications returns the (unique) IDs of the listed and
+// selected applications. Listed applications must exist.
+func (h BatchHandler) applications(r *Batch) (ids []uint, err error) {
+	wanted := make(map[uint]bool)
+	for _, id := range r.Applications {
+		wanted[id] = true
+	}
+	if len(wanted) > 0 {
+		var count int64
+		db := h.DB.Model(&model.Application{})
+		db = db.Where("id IN ?", r.Applications)
+		result := db.Count(&count)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		if int(count) != len(wanted) {
+			err = fmt.Errorf("applications: one or more not found.")
+			return
+		}
+	}
+	if r.Selector != nil && !r.Selector.Empty() {
+		var selected []uint
+		selected, err = r.Selector.Applications(h.DB)
+		if err != nil {
+			return
+		}
+		for _, id := range selected {
+			wanted[id] = true
+		}
+	}
+	if len(wanted) == 0 {
+		err = fmt.Errorf("applications: none listed or selected.")
+		return
+	}
+	for id := range wanted {
+		ids = append(ids, id)
+	}
+	sort.Slice(
+		ids,
+		func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+
+	return
+}
+
+//
+// Batch REST resource.
+// The status and progress are aggregated from the tasks.
+type Batch struct {
+	Resource
+	Addon        string                 `json:"addon" binding:"required"`
+	Data         map[string]interface{} `json:"data" swaggertype:"object"`
+	Priority     int                    `json:"priority,omitempty"`
+	Applications []uint                 `json:"applications,omitempty"`
+	Selector     *task.Selector         `json:"selector,omitempty"`
+	Status       string                 `json:"status"`
+	Total        int                    `json:"total"`
+	Completed    int                    `json:"completed"`
+	Tasks        []BatchTask            `json:"tasks"`
+}
+
+//
+// With updates the resource with the model.
+func (r *Batch) With(m *model.Batch) {
+	r.Resource.With(&m.Model)
+	r.Addon = m.Addon
+	r.Priority = m.Priority
+	_ = json.Unmarshal(m.Data, &r.Data)
+	if len(m.Selector) > 0 {
+		r.Selector = &task.Selector{}
+		_ = json.Unmarshal(m.Selector, r.Selector)
+	}
+	r.Applications = []uint{}
+	r.Tasks = []BatchTask{}
+	for i := range m.Tasks {
+		t := &m.Tasks[i]
+		ref := BatchTask{}
+		ref.With(t)
+		r.Tasks = append(r.Tasks, ref)
+		if t.ApplicationID != nil {
+			r.Applications = append(r.Applications, *t.ApplicationID)
+		}
+	}
+	r.Total = len(m.Tasks)
+	r.Status, r.Completed = aggregate(m.Tasks)
+}
+
+//
+// Model builds a model.
+func (r *Batch) Model() (m *model.Batch) {
+	m = &model.Batch{
+		Addon:    r.Addon,
+		Priority: r.Priority,
+	}
+	m.Data, _ = json.Marshal(r.Data)
+	if r.Selector != nil {
+		m.Selector, _ = json.Marshal(r.Selector)
+	}
+	m.ID = r.ID
+
+	return
+}
+
+//
+// task builds a task for the application.
+func (r *Batch) task(m *model.Batch, addon *crd.Addon, appId uint) (t model.Task) {
+	data := make(map[string]interface{})
+	for k, v := range r.Data {
+		data[k] = v
+	}
+	data["application"] = appId
+	t = model.Task{
+		Name:     addon.Name,
+		Addon:    addon.Name,
+		Image:    addon.Spec.Image,
+		Priority: m.Priority,
+		BatchID:  &m.ID,
+	}
+	t.Data, _ = json.Marshal(data)
+	t.SetApplication()
+
+	return
+}
+
+//
+// BatchTask REST resource.
+type BatchTask struct {
+	Task        uint   `json:"task"`
+	Application *uint  `json:"application,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+//
+// With updates the resource with the model.
+func (r *BatchTask) With(m *model.Task) {
+	r.Task = m.ID
+	r.Application = m.ApplicationID
+	r.Status = m.Status
+	r.Error = m.Error
+}
@@ -1,12 +1,23 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/konveyor/tackle-hub/bucket"
 	"github.com/konveyor/tackle-hub/model"
+	"html"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	pathlib "path"
+	"strconv"
+	"strings"
+	"time"
 )
 
 //
@@ -20,6 +31,29 @@ const (
 	AppBucketContentRoot = AppBucketRoot + "/content/*" + Wildcard
 )
 
+//
+// Bucket content (directory) header.
+// GET: archive - a directory is returned as a (gzip) tar archive.
+// PUT: expand - the (gzip) tar archive is expanded into the directory.
+const (
+	Directory        = "X-Directory"
+	DirectoryArchive = "archive"
+	DirectoryExpand  = "expand"
+)
+
+//
+// Params.
+const (
+	Sha256 = "sha256"
+)
+
+//
+// MIME types.
+const (
+	MIMEOctetStream = "application/octet-stream"
+	MIMETarGz       = "application/x-tar+gzip"
+)
+
 //
 // BucketHandler handles bucket routes.
 type BucketHandler struct {
@@ -35,11 +69,15 @@ func (h BucketHandler) AddRoutes(e *gin.Engine) {
 	e.GET(BucketRoot, h.Get)
 	e.DELETE(BucketRoot, h.Delete)
 	e.GET(BucketContent, h.GetContent)
+	e.PUT(BucketContent, h.PutContent)
+	e.DELETE(BucketContent, h.DeleteContent)
 	e.GET(AppBucketsRoot, h.AppList)
 	e.GET(AppBucketsRoot+"/", h.AppList)
 	e.GET(AppBucketRoot+"/", h.AppGet)
 	e.POST(AppBucketRoot, h.AppCreate)
 	e.GET(AppBucketContentRoot, h.AppContent)
+	e.PUT(AppBucketContentRoot, h.AppPutContent)
+	e.DELETE(AppBucketContentRoot, h.AppDeleteContent)
 }
 
 // Get godoc
@@ -141,13 +179,19 @@ func (h BucketHandler) Delete(ctx *gin.Context) {
 // GetContent godoc
 // @summary Get bucket content by ID and path.
 // @description Get bucket content by ID and path.
+// @description When the X-Directory: archive header is passed, a
+// @description directory is returned as a (gzip) tar archive.
+// @description Otherwise, a directory is returned as a listing (JSON) of
+// @description entries or (html) when requested by the Accept header.
+// @description When sha256=true is passed, the listing includes the file digests.
 // @tags get
-// @produce json
-// @success 200 {object}
-// @router /bucket/{id}/content/* [get]
+// @produce octet-stream,json,html
+// @success 200 {object} []BucketEntry
+// @router /buckets/{id}/content/{wildcard} [get]
 // @param id path string true "Bucket ID"
+// @param wildcard path string true "Content path"
+// @param sha256 query bool false "Include file digests (listing)"
 func (h BucketHandler) GetContent(ctx *gin.Context) {
-	rPath := ctx.Param(Wildcard)
 	m := &model.Bucket{}
 	id := ctx.Param(ID)
 	result := h.DB.First(m, id)
@@ -155,9 +199,52 @@ func (h BucketHandler) GetContent(ctx *gin.Context) {
 		h.getFailed(ctx, result.Error)
 		return
 	}
-	ctx.File(pathlib.Join(
-		m.Path,
-		rPath))
+	h.getContent(ctx, m)
+}
+
+// PutContent godoc
+// @summary Upload bucket content by ID and path.
+// @description Upload bucket content by ID and path.
+// @description The body is written to the file (path).
+// @description When the X-Directory: expand header is passed, the
+// @description (gzip) tar archive is expanded into the directory (path).
+// @description The directory content is replaced.
+// @tags update
+// @accept octet-stream
+// @success 204
+// @router /buckets/{id}/content/{wildcard} [put]
+// @param id path string true "Bucket ID"
+// @param wildcard path string true "Content path"
+func (h BucketHandler) PutContent(ctx *gin.Context) {
+	m := &model.Bucket{}
+	id := ctx.Param(ID)
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	h.putContent(ctx, m)
+}
+
+// DeleteContent godoc
+// @summary Delete bucket content by ID and path.
+// @description Delete bucket content by ID and path.
+// @description When the path is the bucket root, the content
+// @description is deleted but the bucket is not.
+// @tags delete
+// @success 204
+// @router /buckets/{id}/content/{wildcard} [delete]
+// @param id path string true "Bucket ID"
+// @param wildcard path string true "Content path"
+func (h BucketHandler) DeleteContent(ctx *gin.Context) {
+	m := &model.Bucket{}
+	id := ctx.Param(ID)
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	h.deleteContent(ctx, m)
 }
 
 // AppList godoc
@@ -249,36 +336,295 @@ func (h BucketHandler) AppCreate(ctx *gin.Context) {
 // AppContent godoc
 // @summary Get bucket content by application ID, bucket name and path.
 // @description Get bucket content by application ID, bucket name and path.
+// @description When the X-Directory: archive header is passed, a
+// @description directory is returned as a (gzip) tar archive.
+// @description Otherwise, a directory is returned as a listing (JSON) of
+// @description entries or (html) when requested by the Accept header.
+// @description When sha256=true is passed, the listing includes the file digests.
 // @tags get
-// @produce json
-// @success 200 {object}
-// @router /application-inventory/application/{id}/buckets/{name}/content/* [get]
-// @param id path string true "Bucket ID"
+// @produce octet-stream,json,html
+// @success 200 {object} []BucketEntry
+// @router /application-inventory/application/{id}/buckets/{name}/content/{wildcard} [get]
+// @param id path string true "Application ID"
 // @param name path string true "Bucket Name"
+// @param wildcard path string true "Content path"
+// @param sha256 query bool false "Include file digests (listing)"
 func (h BucketHandler) AppContent(ctx *gin.Context) {
-	rPath := ctx.Param(Wildcard)
+	m, err := h.appBucket(ctx)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	h.getContent(ctx, m)
+}
+
+// AppPutContent godoc
+// @summary Upload bucket content by application ID, bucket name and path.
+// @description Upload bucket content by application ID, bucket name and path.
+// @description The body is written to the file (path).
+// @description When the X-Directory: expand header is passed, the
+// @description (gzip) tar archive is expanded into the directory (path).
+// @description The directory content is replaced.
+// @tags update
+// @accept octet-stream
+// @success 204
+// @router /application-inventory/application/{id}/buckets/{name}/content/{wildcard} [put]
+// @param id path string true "Application ID"
+// @param name path string true "Bucket Name"
+// @param wildcard path string true "Content path"
+func (h BucketHandler) AppPutContent(ctx *gin.Context) {
+	m, err := h.appBucket(ctx)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	h.putContent(ctx, m)
+}
+
+// AppDeleteContent godoc
+// @summary Delete bucket content by application ID, bucket name and path.
+// @description Delete bucket content by application ID, bucket name and path.
+// @description When the path is the bucket root, the content
+// @description is deleted but the bucket is not.
+// @tags delete
+// @success 204
+// @router /application-inventory/application/{id}/buckets/{name}/content/{wildcard} [delete]
+// @param id path string true "Application ID"
+// @param name path string true "Bucket Name"
+// @param wildcard path string true "Content path"
+func (h BucketHandler) AppDeleteContent(ctx *gin.Context) {
+	m, err := h.appBucket(ctx)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	h.deleteContent(ctx, m)
+}
+
+//
+// appBucket finds the bucket by application ID and name.
+func (h BucketHandler) appBucket(ctx *gin.Context) (m *model.Bucket, err error) {
 	appID := ctx.Param(ID)
 	name := ctx.Param(Name)
-	m := &model.Bucket{}
+	m = &model.Bucket{}
 	db := h.DB.Where("applicationID", appID).Where("name", name)
 	result := db.First(m)
-	if result.Error != nil {
-		h.getFailed(ctx, result.Error)
+	err = result.Error
+	return
+}
+
+//
+// contentPath returns the content path.
+// The path is rooted in the bucket.
+func (h BucketHandler) contentPath(ctx *gin.Context) (path string) {
+	path = pathlib.Clean("/" + ctx.Param(Wildcard))
+	return
+}
+
+//
+// getContent writes the content (file or directory) to the response.
+// The content is streamed from the storage. Files are served with
+// Content-Type, ETag and Range support. Directories are listed.
+func (h BucketHandler) getContent(ctx *gin.Context, m *model.Bucket) {
+	path := h.contentPath(ctx)
+	storage := bucket.Default
+	info, err := storage.Stat(m.Path, path)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	if info.Dir {
+		if ctx.GetHeader(Directory) == DirectoryArchive {
+			ctx.Header(Directory, DirectoryArchive)
+			ctx.Header("Content-Type", MIMETarGz)
+			ctx.Status(http.StatusOK)
+			err = bucket.Archive(storage, m.Path, path, ctx.Writer)
+			if err != nil {
+				log.Error(err, "Archive failed.", "path", path)
+			}
+			return
+		}
+		h.listContent(ctx, m, path)
 		return
 	}
-	ctx.File(pathlib.Join(
-		m.Path,
-		rPath))
+	object, err := storage.Get(m.Path, path)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	defer func() {
+		_ = object.Close()
+	}()
+	ctx.Header("ETag", h.etag(&info))
+	http.ServeContent(
+		ctx.Writer,
+		ctx.Request,
+		info.Path,
+		info.Modified,
+		object)
+}
+
+//
+// etag returns the entity tag for the file.
+// Based on the size and modified time.
+func (h BucketHandler) etag(info *bucket.Info) (tag string) {
+	tag = fmt.Sprintf(
+		"\"%x-%x\"",
+		info.Size,
+		info.Modified.UnixNano())
+	return
+}
+
+//
+// listContent writes the directory listing.
+// The listing is rendered as html when accepted by the client.
+func (h BucketHandler) listContent(ctx *gin.Context, m *model.Bucket, path string) {
+	storage := bucket.Default
+	list, err := storage.List(m.Path, path)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	digest := false
+	if s, found := ctx.GetQuery(Sha256); found {
+		digest, _ = strconv.ParseBool(s)
+	}
+	resources := []BucketEntry{}
+	for _, info := range list {
+		if strings.Contains(info.Path, "/") {
+			continue
+		}
+		r := BucketEntry{}
+		r.With(&info)
+		if digest && !info.Dir {
+			r.Sha256, err = h.sha256(m, pathlib.Join(path, info.Path))
+			if err != nil {
+				h.getFailed(ctx, err)
+				return
+			}
+		}
+		resources = append(resources, r)
+	}
+	for _, accept := range ctx.Request.Header.Values("Accept") {
+		if strings.Contains(accept, "text/html") {
+			h.renderContent(ctx, path, resources)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, resources)
+}
+
+//
+// renderContent writes the directory listing (html).
+func (h BucketHandler) renderContent(ctx *gin.Context, path string, resources []BucketEntry) {
+	base := ""
+	if !strings.HasSuffix(ctx.Request.URL.Path, "/") {
+		base = pathlib.Base(ctx.Request.URL.Path) + "/"
+	}
+	body := []string{
+		"<html><head><title>" + html.EscapeString(path) + "</title></head><body>",
+		"<h1>" + html.EscapeString(path) + "</h1>",
+		"<table>",
+		"<tr><th>Name</th><th>Size</th><th>Modified</th></tr>",
+	}
+	if path != "/" {
+		body = append(body, "<tr><td><a href=\""+base+"..\">../</a></td></tr>")
+	}
+	for _, r := range resources {
+		name := r.Name
+		size := strconv.FormatInt(r.Size, 10)
+		if r.Kind == KindDir {
+			name += "/"
+			size = "-"
+		}
+		href := base + url.PathEscape(r.Name)
+		body = append(
+			body,
+			"<tr>"+
+				"<td><a href=\""+html.EscapeString(href)+"\">"+html.EscapeString(name)+"</a></td>"+
+				"<td>"+size+"</td>"+
+				"<td>"+r.Modified.Format(time.RFC3339)+"</td>"+
+				"</tr>")
+	}
+	body = append(body, "</table>", "</body></html>")
+	ctx.Data(
+		http.StatusOK,
+		"text/html; charset=utf-8",
+		[]byte(strings.Join(body, "\n")))
+}
+
+//
+// sha256 returns the (hex) sha256 of the file content.
+func (h BucketHandler) sha256(m *model.Bucket, path string) (digest string, err error) {
+	object, err := bucket.Default.Get(m.Path, path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = object.Close()
+	}()
+	hash := sha256.New()
+	_, err = io.Copy(hash, object)
+	if err != nil {
+		return
+	}
+	digest = hex.EncodeToString(hash.Sum(nil))
+	return
+}
+
+//
+// putContent writes the request body to the content path.
+func (h BucketHandler) putContent(ctx *gin.Context, m *model.Bucket) {
+	path := h.contentPath(ctx)
+	storage := bucket.Default
+	var err error
+	switch ctx.GetHeader(Directory) {
+	case DirectoryExpand:
+		err = storage.Remove(m.Path, path)
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		if err == nil {
+			err = bucket.Expand(storage, m.Path, path, ctx.Request.Body)
+		}
+	default:
+		if path == "/" {
+			h.bindFailed(ctx, errors.New("path: file path required."))
+			return
+		}
+		err = storage.Put(m.Path, path, ctx.Request.Body)
+	}
+	if err != nil {
+		h.updateFailed(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+//
+// deleteContent deletes the content (file or directory).
+func (h BucketHandler) deleteContent(ctx *gin.Context, m *model.Bucket) {
+	path := h.contentPath(ctx)
+	err := bucket.Default.Remove(m.Path, path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			h.getFailed(ctx, err)
+		} else {
+			h.deleteFailed(ctx, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
 }
 
 //
 // create a bucket.
 func (h BucketHandler) create(r *Bucket) (err error) {
 	uid := uuid.New()
-	r.Path = pathlib.Join(
-		Settings.Hub.Bucket.Path,
-		uid.String())
-	err = os.MkdirAll(r.Path, 0777)
+	r.Path, err = bucket.Default.Create(uid.String())
 	if err != nil {
 		return
 	}
@@ -287,7 +633,7 @@ func (h BucketHandler) create(r *Bucket) (err error) {
 	result := h.DB.Create(m)
 	err = result.Error
 	if err != nil {
-		_ = os.Remove(r.Path)
+		_ = bucket.Default.Delete(r.Path)
 	}
 	r.With(m)
 
@@ -324,3 +670,34 @@ func (r *Bucket) Model() (m *model.Bucket) {
 
 	return
 }
+
+//
+// Bucket entry kinds.
+const (
+	KindFile = "file"
+	KindDir  = "dir"
+)
+
+//
+// BucketEntry REST resource.
+// An entry in a bucket directory listing.
+type BucketEntry struct {
+	Name     string    `json:"name"`
+	Kind     string    `json:"kind"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Sha256   string    `json:"sha256,omitempty"`
+}
+
+//
+// With updates the resource with the info.
+func (r *BucketEntry) With(info *bucket.Info) {
+	r.Name = info.Path
+	r.Size = info.Size
+	r.Modified = info.Modified
+	r.Kind = KindFile
+	if info.Dir {
+		r.Kind = KindDir
+		r.Size = 0
+	}
+}
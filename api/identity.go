@@ -9,9 +9,11 @@ import (
 //
 // Routes
 const (
-	IdentitiesRoot    = "/identities"
-	IdentityRoot      = IdentitiesRoot + "/:" + ID
-	AppIdentitiesRoot = ApplicationRoot + IdentitiesRoot
+	IdentitiesRoot     = "/identities"
+	IdentityRoot       = IdentitiesRoot + "/:" + ID
+	AppIdentitiesRoot  = ApplicationRoot + IdentitiesRoot
+	TaskIdentitiesRoot = TaskRoot + IdentitiesRoot
+	TaskIdentityRoot   = TaskIdentitiesRoot + "/:" + Key
 )
 
 //
@@ -30,6 +32,9 @@ func (h IdentityHandler) AddRoutes(e *gin.Engine) {
 	e.POST(AppIdentitiesRoot, h.CreateForApplication)
 	e.GET(AppIdentitiesRoot, h.ListByApplication)
 	e.GET(AppIdentitiesRoot+"/", h.ListByApplication)
+	e.GET(TaskIdentitiesRoot, h.ListByTask)
+	e.GET(TaskIdentitiesRoot+"/", h.ListByTask)
+	e.GET(TaskIdentityRoot, h.GetByTask)
 }
 
 // Get godoc
@@ -109,6 +114,81 @@ func (h IdentityHandler) ListByApplication(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, resources)
 }
 
+// ListByTask godoc
+// @summary List (decrypted) identities for a task.
+// @description List (decrypted) identities for the applications
+// @description named in the task data. Requires the task token.
+// @tags get
+// @produce json
+// @success 200 {object} []Identity
+// @router /tasks/{id}/identities [get]
+// @param id path int true "Task ID"
+func (h IdentityHandler) ListByTask(ctx *gin.Context) {
+	var list []model.Identity
+	task := &model.Task{}
+	result := h.DB.First(task, ctx.Param(ID))
+	if result.Error != nil {
+		h.listFailed(ctx, result.Error)
+		return
+	}
+	db := h.DB.Where("applicationid IN ?", taskApplications(task))
+	result = db.Find(&list)
+	if result.Error != nil {
+		h.listFailed(ctx, result.Error)
+		return
+	}
+	resources := []Identity{}
+	for i := range list {
+		m := &list[i]
+		err := m.Decrypt(Settings.Encryption.Passphrase)
+		if err != nil {
+			h.listFailed(ctx, err)
+			return
+		}
+		r := Identity{}
+		r.With(m)
+		resources = append(resources, r)
+	}
+
+	ctx.JSON(http.StatusOK, resources)
+}
+
+// GetByTask godoc
+// @summary Get a (decrypted) identity for a task.
+// @description Get a (decrypted) identity by ID. The identity must
+// @description belong to an application named in the task data.
+// @description Requires the task token.
+// @tags get
+// @produce json
+// @success 200 {object} Identity
+// @router /tasks/{id}/identities/{key} [get]
+// @param id path int true "Task ID"
+// @param key path int true "Identity ID"
+func (h IdentityHandler) GetByTask(ctx *gin.Context) {
+	task := &model.Task{}
+	result := h.DB.First(task, ctx.Param(ID))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	m := &model.Identity{}
+	db := h.DB.Where("applicationid IN ?", taskApplications(task))
+	result = db.First(m, ctx.Param(Key))
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	err := m.Decrypt(Settings.Encryption.Passphrase)
+	if err != nil {
+		h.getFailed(ctx, err)
+		return
+	}
+	r := Identity{}
+	r.With(m)
+
+	ctx.JSON(http.StatusOK, r)
+}
+
 // Create godoc
 // @summary Create an identity.
 // @description Create an identity.
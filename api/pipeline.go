@@ -0,0 +1,662 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle-hub/model"
+	"github.com/konveyor/tackle-hub/task"
+	"gorm.io/gorm"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+//
+// Routes
+const (
+	PipelinesRoot      = "/pipelines"
+	PipelineRoot       = PipelinesRoot + "/:" + ID
+	PipelineRunsRoot   = PipelineRoot + "/runs"
+	PipelineRunRoot    = PipelineRunsRoot + "/:" + Run
+	PipelineCancelRoot = PipelineRunRoot + "/cancel"
+)
+
+//
+// StepNamePattern step names are used to name the task jobs.
+var StepNamePattern = regexp.MustCompile("^[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?$")
+
+//
+// PipelineHandler handles pipeline routes.
+type PipelineHandler struct {
+	BaseHandler
+}
+
+//
+// AddRoutes adds routes.
+func (h PipelineHandler) AddRoutes(e *gin.Engine) {
+	e.GET(PipelinesRoot, h.List)
+	e.GET(PipelinesRoot+"/", h.List)
+	e.POST(PipelinesRoot, h.Create)
+	e.GET(PipelineRoot, h.Get)
+	e.PUT(PipelineRoot, h.Update)
+	e.DELETE(PipelineRoot, h.Delete)
+	e.POST(PipelineRunsRoot, h.Run)
+	e.GET(PipelineRunsRoot, h.RunList)
+	e.GET(PipelineRunsRoot+"/", h.RunList)
+	e.GET(PipelineRunRoot, h.RunGet)
+	e.PUT(PipelineCancelRoot, h.RunCancel)
+}
+
+// Get godoc
+// @summary Get a pipeline by ID.
+// @description Get a pipeline by ID.
+// @tags get
+// @produce json
+// @success 200 {object} api.Pipeline
+// @router /pipelines/{id} [get]
+// @param id path string true "Pipeline ID"
+func (h PipelineHandler) Get(ctx *gin.Context) {
+	m := &model.Pipeline{}
+	id := ctx.Param(ID)
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	r := Pipeline{}
+	r.With(m)
+
+	ctx.JSON(http.StatusOK, r)
+}
+
+// List godoc
+// @summary List all pipelines.
+// @description List all pipelines.
+// @tags get
+// @produce json
+// @success 200 {object} []api.Pipeline
+// @router /pipelines [get]
+func (h PipelineHandler) List(ctx *gin.Context) {
+	var list []model.Pipeline
+	pagination := NewPagination(ctx)
+	db := pagination.apply(h.DB)
+	result := db.Find(&list)
+	if result.Error != nil {
+		h.listFailed(ctx, result.Error)
+		return
+	}
+	resources := []Pipeline{}
+	for i := range list {
+		r := Pipeline{}
+		r.With(&list[i])
+		resources = append(resources, r)
+	}
+
+	ctx.JSON(http.StatusOK, resources)
+}
+
+// Create godoc
+// @summary Create a pipeline.
+// @description Create a pipeline.
+// @tags create
+// @accept json
+// @produce json
+// @success 201 {object} api.Pipeline
+// @router /pipelines [post]
+// @param pipeline body api.Pipeline true "Pipeline data"
+func (h PipelineHandler) Create(ctx *gin.Context) {
+	r := &Pipeline{}
+	err := ctx.BindJSON(r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	_, err = r.Sorted()
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	m := r.Model()
+	result := h.DB.Create(m)
+	if result.Error != nil {
+		h.createFailed(ctx, result.Error)
+		return
+	}
+	r.With(m)
+
+	ctx.JSON(http.StatusCreated, r)
+}
+
+// Delete godoc
+// @summary Delete a pipeline.
+// @description Delete a pipeline.
+// @description Tasks created by the pipeline runs are not deleted.
+// @tags delete
+// @success 204
+// @router /pipelines/{id} [delete]
+// @param id path string true "Pipeline ID"
+func (h PipelineHandler) Delete(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	m := &model.Pipeline{}
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.deleteFailed(ctx, result.Error)
+		return
+	}
+	result = h.DB.Delete(m, id)
+	if result.Error != nil {
+		h.deleteFailed(ctx, result.Error)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Update godoc
+// @summary Update a pipeline.
+// @description Update a pipeline.
+// @description Existing runs are not affected.
+// @tags update
+// @accept json
+// @success 204
+// @router /pipelines/{id} [put]
+// @param id path string true "Pipeline ID"
+// @param pipeline body api.Pipeline true "Pipeline data"
+func (h PipelineHandler) Update(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	r := &Pipeline{}
+	err := ctx.BindJSON(r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	_, err = r.Sorted()
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	m := r.Model()
+	db := h.DB.Model(&model.Pipeline{})
+	db = db.Where("id", id)
+	db = db.Select(
+		"Name",
+		"Description",
+		"Ordered",
+		"Data",
+		"Steps")
+	result := db.Updates(m)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Run godoc
+// @summary Run a pipeline.
+// @description Run a pipeline.
+// @description A task is created for each step.
+// @tags create
+// @produce json
+// @success 201 {object} api.PipelineRun
+// @router /pipelines/{id}/runs [post]
+// @param id path string true "Pipeline ID"
+func (h PipelineHandler) Run(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	m := &model.Pipeline{}
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	pipeline := Pipeline{}
+	pipeline.With(m)
+	steps, err := pipeline.Sorted()
+	if err != nil {
+		h.createFailed(ctx, err)
+		return
+	}
+	run := &model.PipelineRun{PipelineID: m.ID}
+	tasks := []*model.Task{}
+	err = h.DB.Transaction(func(tx *gorm.DB) (err error) {
+		result := tx.Create(run)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		created := make(map[string]uint)
+		for _, step := range steps {
+			t := step.task(&pipeline, run.ID, created)
+			result = tx.Create(t)
+			if result.Error != nil {
+				err = result.Error
+				return
+			}
+			created[step.Name] = t.ID
+			tasks = append(tasks, t)
+		}
+		return
+	})
+	if err != nil {
+		h.createFailed(ctx, err)
+		return
+	}
+	h.created(tasks...)
+	r, err := h.run(m.ID, run.ID)
+	if err != nil {
+		h.createFailed(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, r)
+}
+
+// RunGet godoc
+// @summary Get a pipeline run by ID.
+// @description Get a pipeline run by ID.
+// @tags get
+// @produce json
+// @success 200 {object} api.PipelineRun
+// @router /pipelines/{id}/runs/{run} [get]
+// @param id path string true "Pipeline ID"
+// @param run path string true "Run ID"
+func (h PipelineHandler) RunGet(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	runId := ctx.Param(Run)
+	m := &model.PipelineRun{}
+	db := h.DB.Where("pipelineid", id)
+	db = db.Preload("Tasks.Report")
+	result := db.First(m, runId)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	r := PipelineRun{}
+	r.With(m)
+
+	ctx.JSON(http.StatusOK, r)
+}
+
+// RunList godoc
+// @summary List the runs of a pipeline.
+// @description List the runs of a pipeline.
+// @tags get
+// @produce json
+// @success 200 {object} []api.PipelineRun
+// @router /pipelines/{id}/runs [get]
+// @param id path string true "Pipeline ID"
+func (h PipelineHandler) RunList(ctx *gin.Context) {
+	var list []model.PipelineRun
+	id := ctx.Param(ID)
+	pagination := NewPagination(ctx)
+	db := pagination.apply(h.DB)
+	db = db.Where("pipelineid", id)
+	db = db.Preload("Tasks.Report")
+	result := db.Find(&list)
+	if result.Error != nil {
+		h.listFailed(ctx, result.Error)
+		return
+	}
+	resources := []PipelineRun{}
+	for i := range list {
+		r := PipelineRun{}
+		r.With(&list[i])
+		resources = append(resources, r)
+	}
+
+	ctx.JSON(http.StatusOK, resources)
+}
+
+// RunCancel godoc
+// @summary Cancel a pipeline run.
+// @description Cancel a pipeline run.
+// @description Each task that has not terminated is canceled.
+// @tags update
+// @success 202
+// @router /pipelines/{id}/runs/{run}/cancel [put]
+// @param id path string true "Pipeline ID"
+// @param run path string true "Run ID"
+func (h PipelineHandler) RunCancel(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	runId := ctx.Param(Run)
+	m := &model.PipelineRun{}
+	db := h.DB.Where("pipelineid", id)
+	result := db.First(m, runId)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	db = h.DB.Model(&model.Task{})
+	db = db.Where("pipelinerunid", m.ID)
+	db = db.Where(
+		"status NOT IN ?",
+		[]string{
+			task.Succeeded,
+			task.Failed,
+			task.TimedOut,
+			task.Canceled,
+		})
+	result = db.Update("canceled", true)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+//
+// run builds a run resource.
+func (h PipelineHandler) run(pipelineId, id uint) (r *PipelineRun, err error) {
+	m := &model.PipelineRun{}
+	db := h.DB.Where("pipelineid", pipelineId)
+	db = db.Preload("Tasks.Report")
+	result := db.First(m, id)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	r = &PipelineRun{}
+	r.With(m)
+	return
+}
+
+//
+// Pipeline REST resource.
+type Pipeline struct {
+	Resource
+	Name        string                 `json:"name" binding:"required"`
+	Description string                 `json:"description"`
+	Ordered     bool                   `json:"ordered,omitempty"`
+	Data        map[string]interface{} `json:"data" swaggertype:"object"`
+	Steps       []PipelineStep         `json:"steps" binding:"required,min=1,dive"`
+}
+
+//
+// With updates the resource with the model.
+func (r *Pipeline) With(m *model.Pipeline) {
+	r.Resource.With(&m.Model)
+	r.Name = m.Name
+	r.Description = m.Description
+	r.Ordered = m.Ordered
+	_ = json.Unmarshal(m.Data, &r.Data)
+	_ = json.Unmarshal(m.Steps, &r.Steps)
+}
+
+//
+// Model builds a model.
+func (r *Pipeline) Model() (m *model.Pipeline) {
+	m = &model.Pipeline{
+		Name:        r.Name,
+		Description: r.Description,
+		Ordered:     r.Ordered,
+	}
+	m.Data, _ = json.Marshal(r.Data)
+	m.Steps, _ = json.Marshal(r.Steps)
+	m.ID = r.ID
+
+	return
+}
+
+//
+// Sorted validates the steps and returns them sorted
+// such that each step is listed after the steps on
+// which it depends. The declared order is preserved
+// when possible.
+func (r *Pipeline) Sorted() (sorted []PipelineStep, err error) {
+	steps := make(map[string]*PipelineStep)
+	for i := range r.Steps {
+		step := &r.Steps[i]
+		if !StepNamePattern.MatchString(step.Name) {
+			err = fmt.Errorf("step '%s': name not valid.", step.Name)
+			return
+		}
+		if _, found := steps[step.Name]; found {
+			err = fmt.Errorf("step '%s': name not unique.", step.Name)
+			return
+		}
+		steps[step.Name] = step
+	}
+	deps := make(map[string][]string)
+	for i := range r.Steps {
+		step := &r.Steps[i]
+		deps[step.Name], err = r.dependencies(step)
+		if err != nil {
+			return
+		}
+		for _, name := range deps[step.Name] {
+			if _, found := steps[name]; !found || name == step.Name {
+				err = fmt.Errorf(
+					"step '%s': dependency '%s' not valid.",
+					step.Name,
+					name)
+				return
+			}
+		}
+	}
+	done := make(map[string]bool)
+	for len(sorted) < len(r.Steps) {
+		progress := false
+		for _, step := range r.Steps {
+			if done[step.Name] {
+				continue
+			}
+			ready := true
+			for _, name := range deps[step.Name] {
+				if !done[name] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[step.Name] = true
+				sorted = append(sorted, step)
+				progress = true
+			}
+		}
+		if !progress {
+			err = errors.New("steps contain a dependency cycle.")
+			return
+		}
+	}
+
+	return
+}
+
+//
+// dependencies returns the names of the steps on which the step depends.
+// In an ordered pipeline, each step depends on the preceding step.
+func (r *Pipeline) dependencies(step *PipelineStep) (names []string, err error) {
+	names = append(names, step.DependsOn...)
+	if r.Ordered {
+		for i := 1; i < len(r.Steps); i++ {
+			if r.Steps[i].Name == step.Name {
+				names = append(names, r.Steps[i-1].Name)
+				break
+			}
+		}
+	}
+	for key, ref := range step.Inputs {
+		part := strings.SplitN(ref, ".", 2)
+		if len(part) != 2 || part[0] == "" || part[1] == "" {
+			err = fmt.Errorf(
+				"step '%s': input '%s' must be: <step>.<key>.",
+				step.Name,
+				key)
+			return
+		}
+		names = append(names, part[0])
+	}
+
+	return
+}
+
+//
+// PipelineStep REST resource.
+// Inputs maps a data field to the output of another step.
+// Format: <step>.<key>. A step depends on the steps named
+// in DependsOn and Inputs. In an ordered pipeline, each step
+// also depends on the preceding step.
+type PipelineStep struct {
+	Name      string                 `json:"name" binding:"required"`
+	Addon     string                 `json:"addon" binding:"required"`
+	Data      map[string]interface{} `json:"data,omitempty" swaggertype:"object"`
+	DependsOn []string               `json:"dependsOn,omitempty"`
+	Inputs    map[string]string      `json:"inputs,omitempty"`
+}
+
+//
+// task builds the task for the step.
+// The pipeline data is merged with the step data.
+// The created map contains the IDs of the tasks created
+// for the steps on which the step depends.
+func (r *PipelineStep) task(pipeline *Pipeline, runId uint, created map[string]uint) (m *model.Task) {
+	data := make(map[string]interface{})
+	for k, v := range pipeline.Data {
+		data[k] = v
+	}
+	for k, v := range r.Data {
+		data[k] = v
+	}
+	names, _ := pipeline.dependencies(r)
+	dependsOn := []uint{}
+	for _, name := range names {
+		dependsOn = append(dependsOn, created[name])
+	}
+	inputs := make(map[string]task.Input)
+	for key, ref := range r.Inputs {
+		part := strings.SplitN(ref, ".", 2)
+		inputs[key] = task.Input{
+			Task: created[part[0]],
+			Key:  part[1],
+		}
+	}
+	m = &model.Task{
+		Name:          r.Name,
+		Addon:         r.Addon,
+		Locator:       fmt.Sprintf("pipeline.%s.%d", pipeline.Name, runId),
+		PipelineRunID: &runId,
+	}
+	m.Data, _ = json.Marshal(data)
+	m.SetApplication()
+	m.DependsOn, _ = json.Marshal(dependsOn)
+	m.Inputs, _ = json.Marshal(inputs)
+
+	return
+}
+
+//
+// PipelineRun REST resource.
+// The status is aggregated from the step tasks. The progress
+// is aggregated from the step task reports.
+type PipelineRun struct {
+	Resource
+	Pipeline  uint              `json:"pipeline"`
+	Status    string            `json:"status"`
+	Total     int               `json:"total"`
+	Completed int               `json:"completed"`
+	Steps     []PipelineRunStep `json:"steps"`
+}
+
+//
+// With updates the resource with the model.
+func (r *PipelineRun) With(m *model.PipelineRun) {
+	r.Resource.With(&m.Model)
+	r.Pipeline = m.PipelineID
+	r.Steps = []PipelineRunStep{}
+	for i := range m.Tasks {
+		step := PipelineRunStep{}
+		step.With(&m.Tasks[i])
+		r.Steps = append(r.Steps, step)
+	}
+	r.Status, _ = aggregate(m.Tasks)
+	r.Total, r.Completed = progress(m.Tasks)
+}
+
+//
+// progress returns the total and completed aggregated from
+// the task reports. A task without a report (or total) counts
+// as a single item, completed when the task has succeeded.
+func progress(tasks []model.Task) (total, completed int) {
+	for i := range tasks {
+		m := &tasks[i]
+		succeeded := m.Status == task.Succeeded
+		report := m.Report
+		if report == nil || report.Total == 0 {
+			total++
+			if succeeded {
+				completed++
+			}
+			continue
+		}
+		total += report.Total
+		if succeeded {
+			completed += report.Total
+		} else {
+			completed += report.Completed
+		}
+	}
+	return
+}
+
+//
+// aggregate returns the status aggregated from the tasks
+// and the number completed (succeeded). Tasks with a retry
+// scheduled (after a failed attempt) are active.
+func aggregate(tasks []model.Task) (status string, completed int) {
+	count := make(map[string]int)
+	active := 0
+	for i := range tasks {
+		m := &tasks[i]
+		if m.Status == task.Pending && m.RetryAfter != nil {
+			active++
+			continue
+		}
+		count[m.Status]++
+	}
+	for _, s := range task.Active {
+		active += count[s]
+	}
+	completed = count[task.Succeeded]
+	switch {
+	case completed == len(tasks):
+		status = task.Succeeded
+	case active > 0:
+		status = task.Running
+	case count[task.Failed]+count[task.TimedOut] > 0:
+		status = task.Failed
+	case count[task.Canceled] > 0:
+		status = task.Canceled
+	case completed > 0:
+		status = task.Running
+	default:
+		status = task.Pending
+	}
+	return
+}
+
+//
+// PipelineRunStep REST resource.
+type PipelineRunStep struct {
+	Name   string      `json:"name"`
+	Task   uint        `json:"task"`
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Report *TaskReport `json:"report,omitempty"`
+}
+
+//
+// With updates the resource with the model.
+func (r *PipelineRunStep) With(m *model.Task) {
+	r.Name = m.Name
+	r.Task = m.ID
+	r.Status = m.Status
+	r.Error = m.Error
+	if m.Report != nil {
+		r.Report = &TaskReport{}
+		r.Report.With(m.Report)
+	}
+}
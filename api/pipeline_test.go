@@ -0,0 +1,96 @@
+package api
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestSorted(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	names := func(steps []PipelineStep) (names []string) {
+		for _, step := range steps {
+			names = append(names, step.Name)
+		}
+		return
+	}
+	//
+	// Declared order preserved.
+	pipeline := Pipeline{
+		Steps: []PipelineStep{
+			{Name: "a"},
+			{Name: "b"},
+			{Name: "c"},
+		},
+	}
+	sorted, err := pipeline.Sorted()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(names(sorted)).To(gomega.Equal([]string{"a", "b", "c"}))
+	//
+	// Dependencies and inputs.
+	pipeline = Pipeline{
+		Steps: []PipelineStep{
+			{Name: "a", DependsOn: []string{"c"}},
+			{Name: "b", Inputs: map[string]string{"in": "a.key"}},
+			{Name: "c"},
+		},
+	}
+	sorted, err = pipeline.Sorted()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(names(sorted)).To(gomega.Equal([]string{"c", "a", "b"}))
+	//
+	// Ordered.
+	pipeline = Pipeline{
+		Ordered: true,
+		Steps: []PipelineStep{
+			{Name: "a"},
+			{Name: "b"},
+			{Name: "c", Inputs: map[string]string{"in": "a.key"}},
+		},
+	}
+	sorted, err = pipeline.Sorted()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(names(sorted)).To(gomega.Equal([]string{"a", "b", "c"}))
+	//
+	// Ordered with a cycle.
+	pipeline.Steps[0].DependsOn = []string{"c"}
+	_, err = pipeline.Sorted()
+	g.Expect(err).ToNot(gomega.BeNil())
+}
+
+func TestSortedFailed(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := map[string][]PipelineStep{
+		"name not valid": {
+			{Name: "a_b"},
+		},
+		"name not unique": {
+			{Name: "a"},
+			{Name: "a"},
+		},
+		"dependency not found": {
+			{Name: "a", DependsOn: []string{"b"}},
+		},
+		"dependency on self": {
+			{Name: "a", DependsOn: []string{"a"}},
+		},
+		"input not valid": {
+			{Name: "a"},
+			{Name: "b", Inputs: map[string]string{"in": "a"}},
+		},
+		"input not found": {
+			{Name: "a", Inputs: map[string]string{"in": "b.key"}},
+		},
+		"cycle": {
+			{Name: "a", DependsOn: []string{"c"}},
+			{Name: "b", DependsOn: []string{"a"}},
+			{Name: "c", Inputs: map[string]string{"in": "b.key"}},
+		},
+	}
+	for name, steps := range cases {
+		pipeline := Pipeline{Steps: steps}
+		_, err := pipeline.Sorted()
+		g.Expect(err).ToNot(gomega.BeNil(), name)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/konveyor/controller/pkg/logging"
 	"github.com/konveyor/tackle-hub/settings"
 	"gorm.io/gorm"
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -26,6 +27,7 @@ const (
 	ID       = "id"
 	Key      = "key"
 	Name     = "name"
+	Run      = "run"
 	Wildcard = "wildcard"
 )
 
@@ -35,14 +37,17 @@ func All() []Handler {
 	return []Handler{
 		&AddonHandler{},
 		&ApplicationHandler{},
+		&BatchHandler{},
 		&BucketHandler{},
 		&BusinessServiceHandler{},
 		&DependencyHandler{},
 		&ImportHandler{},
 		&JobFunctionHandler{},
 		&IdentityHandler{},
+		&PipelineHandler{},
 		&ProxyHandler{},
 		&ReviewHandler{},
+		&ScheduleHandler{},
 		&SettingHandler{},
 		&StakeholderHandler{},
 		&StakeholderGroupHandler{},
@@ -55,6 +60,6 @@ func All() []Handler {
 //
 // Handler.
 type Handler interface {
-	With(*gorm.DB, client.Client)
+	With(*gorm.DB, client.Client, kubernetes.Interface)
 	AddRoutes(e *gin.Engine)
 }
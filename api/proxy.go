@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"github.com/konveyor/tackle-hub/model"
 	"net/http"
@@ -10,7 +11,7 @@ import (
 // Routes
 const (
 	ProxiesRoot = "/proxies"
-	ProxyRoot   = ProxiesRoot + "/" + ID
+	ProxyRoot   = ProxiesRoot + "/:" + ID
 )
 
 //
@@ -77,7 +78,7 @@ func (h ProxyHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	ctx.JSON(http.StatusOK, list)
+	ctx.JSON(http.StatusOK, resources)
 }
 
 // Create godoc
@@ -164,10 +165,11 @@ func (h ProxyHandler) Update(ctx *gin.Context) {
 // Proxy REST resource.
 type Proxy struct {
 	Resource
-	Kind       string `json:"kind" binding:"oneof=http https"`
-	Host       string `json:"host" binding:"required"`
-	Port       int    `json:"port" binding:"gt=0"`
-	IdentityID uint   `json:"identity"`
+	Kind       string   `json:"kind" binding:"oneof=http https"`
+	Host       string   `json:"host" binding:"required"`
+	Port       int      `json:"port" binding:"gt=0"`
+	IdentityID uint     `json:"identity"`
+	Excluded   []string `json:"excluded"`
 }
 
 //
@@ -178,6 +180,8 @@ func (r *Proxy) With(m *model.Proxy) {
 	r.Host = m.Host
 	r.Port = m.Port
 	r.IdentityID = m.IdentityID
+	r.Excluded = []string{}
+	_ = json.Unmarshal(m.Excluded, &r.Excluded)
 }
 
 //
@@ -190,6 +194,9 @@ func (r *Proxy) Model() (m *model.Proxy) {
 		IdentityID: r.IdentityID,
 	}
 	m.ID = r.ID
+	if r.Excluded != nil {
+		m.Excluded, _ = json.Marshal(r.Excluded)
+	}
 
 	return
 }
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle-hub/cron"
+	"github.com/konveyor/tackle-hub/model"
+	"github.com/konveyor/tackle-hub/task"
+	"net/http"
+	"time"
+)
+
+//
+// Routes
+const (
+	SchedulesRoot = "/schedules"
+	ScheduleRoot  = SchedulesRoot + "/:" + ID
+)
+
+//
+// ScheduleHandler handles schedule routes.
+type ScheduleHandler struct {
+	BaseHandler
+}
+
+//
+// AddRoutes adds routes.
+func (h ScheduleHandler) AddRoutes(e *gin.Engine) {
+	e.GET(SchedulesRoot, h.List)
+	e.GET(SchedulesRoot+"/", h.List)
+	e.POST(SchedulesRoot, h.Create)
+	e.GET(ScheduleRoot, h.Get)
+	e.PUT(ScheduleRoot, h.Update)
+	e.DELETE(ScheduleRoot, h.Delete)
+}
+
+// Get godoc
+// @summary Get a schedule by ID.
+// @description Get a schedule by ID.
+// @tags get
+// @produce json
+// @success 200 {object} api.Schedule
+// @router /schedules/{id} [get]
+// @param id path string true "Schedule ID"
+func (h ScheduleHandler) Get(ctx *gin.Context) {
+	m := &model.Schedule{}
+	id := ctx.Param(ID)
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	r := Schedule{}
+	r.With(m)
+
+	ctx.JSON(http.StatusOK, r)
+}
+
+// List godoc
+// @summary List all schedules.
+// @description List all schedules.
+// @tags get
+// @produce json
+// @success 200 {object} []api.Schedule
+// @router /schedules [get]
+func (h ScheduleHandler) List(ctx *gin.Context) {
+	var list []model.Schedule
+	pagination := NewPagination(ctx)
+	db := pagination.apply(h.DB)
+	result := db.Find(&list)
+	if result.Error != nil {
+		h.listFailed(ctx, result.Error)
+		return
+	}
+	resources := []Schedule{}
+	for i := range list {
+		r := Schedule{}
+		r.With(&list[i])
+		resources = append(resources, r)
+	}
+
+	ctx.JSON(http.StatusOK, resources)
+}
+
+// Create godoc
+// @summary Create a schedule.
+// @description Create a schedule.
+// @tags create
+// @accept json
+// @produce json
+// @success 201 {object} api.Schedule
+// @router /schedules [post]
+// @param schedule body api.Schedule true "Schedule data"
+func (h ScheduleHandler) Create(ctx *gin.Context) {
+	r := &Schedule{}
+	err := ctx.BindJSON(r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	m := r.Model()
+	err = h.next(m)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	result := h.DB.Create(m)
+	if result.Error != nil {
+		h.createFailed(ctx, result.Error)
+		return
+	}
+	r.With(m)
+
+	ctx.JSON(http.StatusCreated, r)
+}
+
+// Delete godoc
+// @summary Delete a schedule.
+// @description Delete a schedule.
+// @description Tasks created by the schedule are not deleted.
+// @tags delete
+// @success 204
+// @router /schedules/{id} [delete]
+// @param id path string true "Schedule ID"
+func (h ScheduleHandler) Delete(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	m := &model.Schedule{}
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.deleteFailed(ctx, result.Error)
+		return
+	}
+	result = h.DB.Delete(m, id)
+	if result.Error != nil {
+		h.deleteFailed(ctx, result.Error)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Update godoc
+// @summary Update a schedule.
+// @description Update a schedule.
+// @description The next run is recalculated.
+// @tags update
+// @accept json
+// @success 204
+// @router /schedules/{id} [put]
+// @param id path string true "Schedule ID"
+// @param schedule body api.Schedule true "Schedule data"
+func (h ScheduleHandler) Update(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	r := &Schedule{}
+	err := ctx.BindJSON(r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	m := r.Model()
+	err = h.next(m)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := h.DB.Model(&model.Schedule{})
+	db = db.Where("id", id)
+	db = db.Select(
+		"Name",
+		"Cron",
+		"Addon",
+		"Data",
+		"Selector",
+		"NextRun")
+	result := db.Updates(m)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+//
+// next validates the cron expression and sets the next run.
+func (h ScheduleHandler) next(m *model.Schedule) (err error) {
+	parsed, err := cron.Parse(m.Cron)
+	if err != nil {
+		return
+	}
+	next := parsed.Next(time.Now())
+	if next.IsZero() {
+		err = errors.New("cron: expression never matched.")
+		return
+	}
+	m.NextRun = &next
+	return
+}
+
+//
+// Schedule REST resource.
+type Schedule struct {
+	Resource
+	Name     string         `json:"name" binding:"required"`
+	Cron     string         `json:"cron" binding:"required"`
+	Addon    string         `json:"addon" binding:"required"`
+	Data     interface{}    `json:"data" swaggertype:"object"`
+	Selector *task.Selector `json:"selector,omitempty"`
+	LastRun  *time.Time     `json:"lastRun"`
+	NextRun  *time.Time     `json:"nextRun"`
+}
+
+//
+// With updates the resource with the model.
+func (r *Schedule) With(m *model.Schedule) {
+	r.Resource.With(&m.Model)
+	r.Name = m.Name
+	r.Cron = m.Cron
+	r.Addon = m.Addon
+	r.LastRun = m.LastRun
+	r.NextRun = m.NextRun
+	_ = json.Unmarshal(m.Data, &r.Data)
+	_ = json.Unmarshal(m.Selector, &r.Selector)
+}
+
+//
+// Model builds a model.
+func (r *Schedule) Model() (m *model.Schedule) {
+	m = &model.Schedule{
+		Name:  r.Name,
+		Cron:  r.Cron,
+		Addon: r.Addon,
+	}
+	m.Data, _ = json.Marshal(r.Data)
+	if r.Selector != nil {
+		m.Selector, _ = json.Marshal(r.Selector)
+	}
+	m.ID = r.ID
+
+	return
+}
@@ -3,29 +3,61 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	crd "github.com/konveyor/tackle-hub/k8s/api/tackle/v1alpha1"
 	"github.com/konveyor/tackle-hub/model"
+	"github.com/konveyor/tackle-hub/task"
+	"gorm.io/gorm"
+	"io"
 	batch "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"net/http"
 	"path"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strconv"
+	"strings"
 	"time"
 )
 
+//
+// Kind
+const (
+	TaskKind = "task"
+)
+
 //
 // Routes
 const (
 	TasksRoot      = "/tasks"
 	TaskRoot       = TasksRoot + "/:" + ID
 	TaskReportRoot = TaskRoot + "/report"
+	TaskResultRoot = TaskRoot + "/result"
+	TaskCancelRoot = TaskRoot + "/cancel"
+	TaskLogRoot    = TaskRoot + "/log"
+	TaskEventsRoot = TaskRoot + "/events"
+	EventsRoot     = TasksRoot + "/events"
 	AddonTasksRoot = AddonRoot + "/tasks"
 )
 
+//
+// KeepAlive interval for event streams.
+const KeepAlive = time.Second * 30
+
 const (
-	LocatorParam = "locator"
+	LocatorParam          = "locator"
+	FollowParam           = "follow"
+	StatusParam           = "status"
+	AddonParam            = "addon"
+	NameParam             = "name"
+	ApplicationParam      = "application"
+	BatchParam            = "batch"
+	CreatedAfterParam     = "createdAfter"
+	CreatedBeforeParam    = "createdBefore"
+	StartedAfterParam     = "startedAfter"
+	StartedBeforeParam    = "startedBefore"
+	TerminatedAfterParam  = "terminatedAfter"
+	TerminatedBeforeParam = "terminatedBefore"
 )
 
 //
@@ -42,8 +74,13 @@ func (h TaskHandler) AddRoutes(e *gin.Engine) {
 	e.POST(TasksRoot, h.Create)
 	e.GET(TaskRoot, h.Get)
 	e.PUT(TaskRoot, h.Update)
+	e.PUT(TaskCancelRoot, h.Cancel)
+	e.GET(TaskLogRoot, h.Log)
+	e.GET(TaskEventsRoot, h.Events)
+	e.GET(EventsRoot, h.AllEvents)
 	e.POST(TaskReportRoot, h.CreateReport)
 	e.PUT(TaskReportRoot, h.UpdateReport)
+	e.GET(TaskResultRoot, h.Result)
 	e.POST(AddonTasksRoot, h.AddonCreate)
 	e.GET(AddonTasksRoot, h.AddonList)
 	e.DELETE(TaskRoot, h.Delete)
@@ -61,6 +98,7 @@ func (h TaskHandler) Get(ctx *gin.Context) {
 	task := &model.Task{}
 	id := ctx.Param(ID)
 	db := h.DB.Preload("Report")
+	db = db.Preload("Attempts")
 	result := db.First(task, id)
 	if result.Error != nil {
 		h.getFailed(ctx, result.Error)
@@ -79,16 +117,38 @@ func (h TaskHandler) Get(ctx *gin.Context) {
 // @produce json
 // @success 200 {object} []api.Task
 // @router /tasks [get]
+// @param locator query string false "Locator"
+// @param status query []string false "Status (multiple)"
+// @param addon query string false "Addon name"
+// @param name query string false "Name contains"
+// @param application query int false "Application ID"
+// @param batch query int false "Batch ID"
+// @param createdAfter query string false "RFC3339 time or duration (ago)"
+// @param createdBefore query string false "RFC3339 time or duration (ago)"
+// @param startedAfter query string false "RFC3339 time or duration (ago)"
+// @param startedBefore query string false "RFC3339 time or duration (ago)"
+// @param terminatedAfter query string false "RFC3339 time or duration (ago)"
+// @param terminatedBefore query string false "RFC3339 time or duration (ago)"
 func (h TaskHandler) List(ctx *gin.Context) {
+	var count int64
 	var list []model.Task
-	pagination := NewPagination(ctx)
-	db := pagination.apply(h.DB)
-	locator := ctx.Query(LocatorParam)
-	if locator != "" {
-		db = db.Where("locator", locator)
+	filter := TaskFilter{}
+	err := filter.With(ctx)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	db := filter.apply(h.DB.Model(&model.Task{}))
+	result := db.Count(&count)
+	if result.Error != nil {
+		h.listFailed(ctx, result.Error)
+		return
 	}
+	pagination := NewPagination(ctx)
+	db = filter.apply(pagination.apply(h.DB))
 	db = db.Preload("Report")
-	result := db.Find(&list)
+	db = db.Preload("Attempts")
+	result = db.Find(&list)
 	if result.Error != nil {
 		h.listFailed(ctx, result.Error)
 		return
@@ -100,7 +160,7 @@ func (h TaskHandler) List(ctx *gin.Context) {
 		resources = append(resources, r)
 	}
 
-	ctx.JSON(http.StatusOK, resources)
+	h.listResponse(ctx, TaskKind, resources, int(count))
 }
 
 // Create godoc
@@ -119,6 +179,11 @@ func (h TaskHandler) Create(ctx *gin.Context) {
 		h.createFailed(ctx, err)
 		return
 	}
+	err = h.dependencies(0, task.DependsOn, task.Inputs)
+	if err != nil {
+		h.dependenciesFailed(ctx, err)
+		return
+	}
 
 	m := task.Model()
 	m.Reset()
@@ -128,6 +193,7 @@ func (h TaskHandler) Create(ctx *gin.Context) {
 		return
 	}
 	task.With(m)
+	h.created(m)
 
 	ctx.JSON(http.StatusCreated, task)
 }
@@ -148,6 +214,9 @@ func (h TaskHandler) Delete(ctx *gin.Context) {
 		return
 	}
 	if task.Job != "" {
+		if h.clusterMissing(ctx) {
+			return
+		}
 		job := &batch.Job{}
 		job.Namespace = path.Dir(task.Job)
 		job.Name = path.Base(task.Job)
@@ -183,8 +252,14 @@ func (h TaskHandler) Update(ctx *gin.Context) {
 	if err != nil {
 		return
 	}
+	taskId, _ := strconv.Atoi(id)
+	err = h.dependencies(uint(taskId), updates.DependsOn, updates.Inputs)
+	if err != nil {
+		h.dependenciesFailed(ctx, err)
+		return
+	}
 	m := updates.Model()
-	result := h.DB.Model(&Task{}).Where("id", id).Omit("id").Updates(m)
+	result := h.DB.Model(&model.Task{}).Where("id", id).Omit("id").Updates(m)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
 		return
@@ -193,6 +268,297 @@ func (h TaskHandler) Update(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
+//
+// dependencies validates the tasks on which the task (ID)
+// depends. The tasks must exist and must not depend on the
+// task (directly or indirectly). Input tasks must be dependencies
+// so the inputs are reported before the task is started.
+func (h TaskHandler) dependencies(id uint, dependsOn []uint, inputs map[string]task.Input) (err error) {
+	for name, input := range inputs {
+		found := false
+		for _, depId := range dependsOn {
+			if depId == input.Task {
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = fmt.Errorf(
+				"input '%s' task (id=%d) not a dependency.",
+				name,
+				input.Task)
+			return
+		}
+	}
+	visited := map[uint]bool{}
+	next := dependsOn
+	for depth := 0; len(next) > 0; depth++ {
+		ids := []uint{}
+		for _, depId := range next {
+			if depId == id {
+				err = fmt.Errorf("dependency task (id=%d) cycle.", depId)
+				return
+			}
+			if !visited[depId] {
+				visited[depId] = true
+				ids = append(ids, depId)
+			}
+		}
+		if len(ids) == 0 {
+			break
+		}
+		list := []model.Task{}
+		result := h.DB.Find(&list, ids)
+		if result.Error != nil {
+			err = result.Error
+			return
+		}
+		if depth == 0 && len(list) < len(ids) {
+			found := map[uint]bool{}
+			for i := range list {
+				found[list[i].ID] = true
+			}
+			for _, depId := range ids {
+				if !found[depId] {
+					err = fmt.Errorf("dependency task (id=%d) not found.", depId)
+					return
+				}
+			}
+		}
+		next = []uint{}
+		for i := range list {
+			depIds := []uint{}
+			_ = json.Unmarshal(list[i].DependsOn, &depIds)
+			next = append(next, depIds...)
+		}
+	}
+	return
+}
+
+//
+// dependenciesFailed handles dependencies() errors.
+func (h TaskHandler) dependenciesFailed(ctx *gin.Context, err error) {
+	ctx.JSON(
+		http.StatusBadRequest,
+		gin.H{
+			"error": err.Error(),
+		})
+}
+
+// Cancel godoc
+// @summary Cancel a task.
+// @description Cancel a task.
+// @description The job and secret are deleted by the task manager.
+// @description The task report is preserved.
+// @tags update
+// @success 202
+// @router /tasks/{id}/cancel [put]
+// @param id path string true "Task ID"
+func (h TaskHandler) Cancel(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	m := &model.Task{}
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	switch m.Status {
+	case task.Succeeded,
+		task.Failed,
+		task.TimedOut,
+		task.Canceled:
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"error": "task already terminated.",
+			})
+		return
+	}
+	db := h.DB.Model(m)
+	db = db.Where("id", id)
+	result = db.Update("canceled", true)
+	if result.Error != nil {
+		h.updateFailed(ctx, result.Error)
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+// Log godoc
+// @summary Get the container logs for a task.
+// @description Get the container logs for a task.
+// @description Logs collected when the task terminated are returned.
+// @description While the task is running, the logs are read from the pods
+// @description and are streamed until the containers terminate when follow=true.
+// @tags get
+// @produce plain
+// @success 200 {string} string
+// @router /tasks/{id}/log [get]
+// @param id path string true "Task ID"
+// @param follow query bool false "Stream while running"
+func (h TaskHandler) Log(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	m := &model.Task{}
+	db := h.DB.Preload("Logs")
+	result := db.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	var collector *task.Log
+	if task.IsActive(m.Status) && m.Job != "" {
+		if h.clusterMissing(ctx) {
+			return
+		}
+		collector = &task.Log{
+			Client:    h.Client,
+			ClientSet: h.ClientSet,
+		}
+	}
+	ctx.Header("Content-Type", "text/plain; charset=utf-8")
+	ctx.Status(http.StatusOK)
+	for i := range m.Logs {
+		entry := &m.Logs[i]
+		_, _ = fmt.Fprintln(ctx.Writer, task.Header(entry.Pod, entry.Container))
+		_, _ = fmt.Fprint(ctx.Writer, entry.Content)
+	}
+	if collector == nil {
+		return
+	}
+	follow, _ := strconv.ParseBool(ctx.Query(FollowParam))
+	writer := &flushWriter{writer: ctx.Writer}
+	err := collector.Copy(m.Job, follow, writer)
+	if err != nil {
+		_, _ = fmt.Fprintln(writer, err.Error())
+	}
+}
+
+// Events godoc
+// @summary Stream task events.
+// @description Stream (SSE) task events.
+// @description An event is sent when the task status or the report
+// @description changes. The data is the task. The first event contains
+// @description the current state. The stream ends when the task has
+// @description terminated.
+// @tags get
+// @produce text/event-stream
+// @success 200 {object} api.Task
+// @router /tasks/{id}/events [get]
+// @param id path string true "Task ID"
+func (h TaskHandler) Events(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	m := &model.Task{}
+	result := h.DB.First(m, id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	subscriber := task.Notifier.Subscribe(m.ID)
+	defer task.Notifier.Unsubscribe(subscriber)
+	h.streamHeaders(ctx)
+	initial := task.Notification{
+		Kind:   task.Updated,
+		Task:   m.ID,
+		Status: m.Status,
+	}
+	terminated, err := h.send(ctx, initial)
+	if err != nil || terminated {
+		return
+	}
+	h.stream(ctx, subscriber, true)
+}
+
+// AllEvents godoc
+// @summary Stream events for all tasks.
+// @description Stream (SSE) events for all tasks.
+// @description An event is sent when a task is created, when the task
+// @description status changes or when the report changes. The data is
+// @description the task.
+// @tags get
+// @produce text/event-stream
+// @success 200 {object} api.Task
+// @router /tasks/events [get]
+func (h TaskHandler) AllEvents(ctx *gin.Context) {
+	subscriber := task.Notifier.Subscribe(0)
+	defer task.Notifier.Unsubscribe(subscriber)
+	h.streamHeaders(ctx)
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+	h.stream(ctx, subscriber, false)
+}
+
+//
+// stream notifications as server-sent events.
+// A comment is sent periodically to keep the connection alive.
+// When once=true, the stream ends when the task has terminated.
+func (h TaskHandler) stream(ctx *gin.Context, subscriber *task.Subscriber, once bool) {
+	keepAlive := time.NewTicker(KeepAlive)
+	defer keepAlive.Stop()
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case n, open := <-subscriber.Channel:
+			if !open {
+				return false
+			}
+			terminated, err := h.send(ctx, n)
+			if err != nil {
+				return !once
+			}
+			return !(once && terminated)
+		}
+	})
+}
+
+//
+// streamHeaders sets the event stream headers.
+func (h TaskHandler) streamHeaders(ctx *gin.Context) {
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+}
+
+//
+// send the event for the notification.
+// Returns terminated=true when the task has terminated.
+func (h TaskHandler) send(ctx *gin.Context, n task.Notification) (terminated bool, err error) {
+	m := &model.Task{}
+	db := h.DB.Preload("Report")
+	result := db.First(m, n.Task)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	r := Task{}
+	r.With(m)
+	ctx.SSEvent(n.Kind, r)
+	ctx.Writer.Flush()
+	switch m.Status {
+	case task.Succeeded,
+		task.Failed,
+		task.TimedOut,
+		task.Canceled:
+		terminated = true
+	}
+
+	return
+}
+
+//
+// reported publishes a task report notification.
+func (h TaskHandler) reported(id uint) {
+	task.Notifier.Publish(
+		task.Notification{
+			Kind: task.Report,
+			Task: id,
+		})
+}
+
 // CreateReport godoc
 // @summary Create a task report.
 // @description Update a task report.
@@ -218,6 +584,7 @@ func (h TaskHandler) CreateReport(ctx *gin.Context) {
 		h.createFailed(ctx, result.Error)
 	}
 	report.With(m)
+	h.reported(m.TaskID)
 
 	ctx.JSON(http.StatusCreated, report)
 }
@@ -234,32 +601,82 @@ func (h TaskHandler) CreateReport(ctx *gin.Context) {
 // @param task body api.TaskReport true "TaskReport data"
 func (h TaskHandler) UpdateReport(ctx *gin.Context) {
 	id := ctx.Param(ID)
+	body, err := ctx.GetRawData()
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
 	report := &TaskReport{}
-	err := ctx.BindJSON(report)
+	err = json.Unmarshal(body, report)
 	if err != nil {
+		h.bindFailed(ctx, err)
 		return
 	}
+	// Fields omitted by the addon are not overwritten.
+	fields := map[string]json.RawMessage{}
+	_ = json.Unmarshal(body, &fields)
 	task, _ := strconv.Atoi(id)
 	report.TaskID = uint(task)
 	m := report.Model()
+	updates := map[string]interface{}{
+		"status":    m.Status,
+		"error":     m.Error,
+		"total":     m.Total,
+		"completed": m.Completed,
+		"activity":  m.Activity,
+	}
+	if _, found := fields["errors"]; found {
+		updates["errors"] = m.Errors
+	}
+	if _, found := fields["output"]; found {
+		updates["output"] = m.Output
+	}
+	if _, found := fields["result"]; found {
+		updates["result"] = m.Result
+	}
 	db := h.DB.Model(&model.TaskReport{})
 	db = db.Where("taskid", task)
-	result := db.Updates(
-		map[string]interface{}{
-			"status":    m.Status,
-			"error":     m.Error,
-			"total":     m.Total,
-			"completed": m.Completed,
-			"activity":  m.Activity,
-		})
+	result := db.Updates(updates)
 	if result.Error != nil {
 		h.updateFailed(ctx, result.Error)
+		return
+	}
+	m = &model.TaskReport{}
+	result = h.DB.First(m, "taskid", task)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
 	}
 	report.With(m)
+	h.reported(m.TaskID)
 
 	ctx.JSON(http.StatusOK, report)
 }
 
+// Result godoc
+// @summary Get the task result.
+// @description Get the (structured) result document reported by the addon.
+// @tags get
+// @produce json
+// @success 200 {object} object
+// @router /tasks/{id}/result [get]
+// @param id path string true "Task ID"
+func (h TaskHandler) Result(ctx *gin.Context) {
+	id := ctx.Param(ID)
+	m := &model.TaskReport{}
+	result := h.DB.First(m, "taskid", id)
+	if result.Error != nil {
+		h.getFailed(ctx, result.Error)
+		return
+	}
+	if len(m.Result) == 0 {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", m.Result)
+}
+
 // AddonCreate godoc
 // @summary Create an addon task.
 // @description Create an addon task.
@@ -268,8 +685,11 @@ func (h TaskHandler) UpdateReport(ctx *gin.Context) {
 // @produce json
 // @success 201 {object} api.Task
 // @router /addons/:name/tasks [post]
-// @param task body api.Task true "Task data"
+// @param task body api.AddonTask true "Task data"
 func (h TaskHandler) AddonCreate(ctx *gin.Context) {
+	if h.clusterMissing(ctx) {
+		return
+	}
 	name := ctx.Param(Name)
 	addon := &crd.Addon{}
 	err := h.Client.Get(
@@ -285,14 +705,29 @@ func (h TaskHandler) AddonCreate(ctx *gin.Context) {
 			return
 		}
 	}
+	body, err := ctx.GetRawData()
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	r := &AddonTask{}
+	err = json.Unmarshal(body, r)
+	if err != nil {
+		h.bindFailed(ctx, err)
+		return
+	}
+	// A body without data is the task data.
+	if r.Data == nil {
+		_ = json.Unmarshal(body, &r.Data)
+	}
 	task := Task{}
 	task.Name = addon.Name
 	task.Addon = addon.Name
 	task.Image = addon.Spec.Image
-	err = ctx.BindJSON(&task.Data)
-	if err != nil {
-		return
-	}
+	task.Locator = r.Locator
+	task.Priority = r.Priority
+	task.Isolated = r.Isolated
+	task.Data = r.Data
 	m := task.Model()
 	result := h.DB.Create(m)
 	if result.Error != nil {
@@ -300,6 +735,7 @@ func (h TaskHandler) AddonCreate(ctx *gin.Context) {
 		return
 	}
 	task.With(m)
+	h.created(m)
 
 	ctx.JSON(http.StatusCreated, task)
 }
@@ -322,6 +758,7 @@ func (h TaskHandler) AddonList(ctx *gin.Context) {
 		db = db.Where("locator", locator)
 	}
 	db = db.Preload("Report")
+	db = db.Preload("Attempts")
 	result := db.Find(&list)
 	if result.Error != nil {
 		h.listFailed(ctx, result.Error)
@@ -343,6 +780,7 @@ type AddonTask struct {
 	Resource
 	Name     string      `json:"name"`
 	Locator  string      `json:"locator"`
+	Priority int         `json:"priority,omitempty"`
 	Isolated bool        `json:"isolated,omitempty"`
 	Data     interface{} `json:"data" swaggertype:"object"`
 }
@@ -351,18 +789,30 @@ type AddonTask struct {
 // Task REST resource.
 type Task struct {
 	Resource
-	Name       string      `json:"name"`
-	Locator    string      `json:"locator"`
-	Isolated   bool        `json:"isolated,omitempty"`
-	Data       interface{} `json:"data" swaggertype:"object"`
-	Addon      string      `json:"addon"`
-	Image      string      `json:"image"`
-	Started    *time.Time  `json:"started"`
-	Terminated *time.Time  `json:"terminated"`
-	Status     string      `json:"status"`
-	Error      string      `json:"error"`
-	Job        string      `json:"job"`
-	Report     *TaskReport `json:"report"`
+	Name        string                `json:"name"`
+	Locator     string                `json:"locator"`
+	Priority    int                   `json:"priority,omitempty"`
+	Isolated    bool                  `json:"isolated,omitempty"`
+	DependsOn   []uint                `json:"dependsOn,omitempty"`
+	Inputs      map[string]task.Input `json:"inputs,omitempty"`
+	Data        interface{}           `json:"data" swaggertype:"object"`
+	Addon       string                `json:"addon"`
+	Image       string                `json:"image"`
+	Started     *time.Time            `json:"started"`
+	Terminated  *time.Time            `json:"terminated"`
+	Status      string                `json:"status"`
+	Error       string                `json:"error"`
+	Events      []task.Event          `json:"events,omitempty"`
+	Job         string                `json:"job"`
+	Canceled    bool                  `json:"canceled,omitempty"`
+	Timeout     int                   `json:"timeout,omitempty"`
+	PipelineRun *uint                 `json:"pipelineRun,omitempty"`
+	Batch       *uint                 `json:"batch,omitempty"`
+	MaxAttempts int                   `json:"maxAttempts,omitempty"`
+	Backoff     int                   `json:"backoff,omitempty"`
+	RetryAfter  *time.Time            `json:"retryAfter,omitempty"`
+	Attempts    []TaskAttempt         `json:"attempts,omitempty"`
+	Report      *TaskReport           `json:"report"`
 }
 
 //
@@ -373,12 +823,28 @@ func (r *Task) With(m *model.Task) {
 	r.Image = m.Image
 	r.Addon = m.Addon
 	r.Locator = m.Locator
+	r.Priority = m.Priority
 	r.Isolated = m.Isolated
+	_ = json.Unmarshal(m.DependsOn, &r.DependsOn)
+	_ = json.Unmarshal(m.Inputs, &r.Inputs)
 	r.Started = m.Started
 	r.Terminated = m.Terminated
 	r.Status = m.Status
 	r.Error = m.Error
+	_ = json.Unmarshal(m.Events, &r.Events)
 	r.Job = m.Job
+	r.Canceled = m.Canceled
+	r.Timeout = m.Timeout
+	r.PipelineRun = m.PipelineRunID
+	r.Batch = m.BatchID
+	r.MaxAttempts = m.MaxAttempts
+	r.Backoff = m.Backoff
+	r.RetryAfter = m.RetryAfter
+	for i := range m.Attempts {
+		attempt := TaskAttempt{}
+		attempt.With(&m.Attempts[i])
+		r.Attempts = append(r.Attempts, attempt)
+	}
 	_ = json.Unmarshal(m.Data, &r.Data)
 	if m.Report != nil {
 		report := &TaskReport{}
@@ -391,26 +857,66 @@ func (r *Task) With(m *model.Task) {
 // Model builds a model.
 func (r *Task) Model() (m *model.Task) {
 	m = &model.Task{
-		Name:     r.Name,
-		Addon:    r.Addon,
-		Locator:  r.Locator,
-		Isolated: r.Isolated,
+		Name:        r.Name,
+		Addon:       r.Addon,
+		Locator:     r.Locator,
+		Priority:    r.Priority,
+		Isolated:    r.Isolated,
+		MaxAttempts: r.MaxAttempts,
+		Backoff:     r.Backoff,
+		Timeout:     r.Timeout,
 	}
 	m.Data, _ = json.Marshal(r.Data)
+	m.SetApplication()
+	if len(r.DependsOn) > 0 {
+		m.DependsOn, _ = json.Marshal(r.DependsOn)
+	}
+	if len(r.Inputs) > 0 {
+		m.Inputs, _ = json.Marshal(r.Inputs)
+	}
 	m.ID = r.ID
 	return
 }
 
+//
+// TaskAttempt REST resource.
+type TaskAttempt struct {
+	Resource
+	Job        string     `json:"job"`
+	Started    *time.Time `json:"started"`
+	Terminated *time.Time `json:"terminated"`
+	Status     string     `json:"status"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
+//
+// With updates the resource with the model.
+func (r *TaskAttempt) With(m *model.TaskAttempt) {
+	r.Resource.With(&m.Model)
+	r.Job = m.Job
+	r.Started = m.Started
+	r.Terminated = m.Terminated
+	r.Status = m.Status
+	r.Reason = m.Reason
+}
+
 //
 // TaskReport REST resource.
+// The activity (messages) is provided for compatibility
+// with addons that report activity as strings. The structured
+// activity is reported in the entries.
 type TaskReport struct {
 	Resource
-	Status    string   `json:"status"`
-	Error     string   `json:"error"`
-	Total     int      `json:"total"`
-	Completed int      `json:"completed"`
-	Activity  []string `json:"activity"`
-	TaskID    uint     `json:"task"`
+	Status    string                 `json:"status"`
+	Error     string                 `json:"error"`
+	Errors    []TaskReportEntry      `json:"errors,omitempty"`
+	Total     int                    `json:"total"`
+	Completed int                    `json:"completed"`
+	Activity  []string               `json:"activity"`
+	Entries   []TaskReportEntry      `json:"entries,omitempty"`
+	Output    map[string]interface{} `json:"output,omitempty"`
+	Result    interface{}            `json:"result,omitempty" swaggertype:"object"`
+	TaskID    uint                   `json:"task"`
 }
 
 //
@@ -422,11 +928,21 @@ func (r *TaskReport) With(m *model.TaskReport) {
 	r.Total = m.Total
 	r.Completed = m.Completed
 	r.TaskID = m.TaskID
-	_ = json.Unmarshal(m.Activity, &r.Activity)
+	r.Entries = []TaskReportEntry{}
+	_ = json.Unmarshal(m.Activity, &r.Entries)
+	r.Activity = []string{}
+	for _, entry := range r.Entries {
+		r.Activity = append(r.Activity, entry.Message)
+	}
+	_ = json.Unmarshal(m.Errors, &r.Errors)
+	_ = json.Unmarshal(m.Output, &r.Output)
+	_ = json.Unmarshal(m.Result, &r.Result)
 }
 
 //
 // Model builds a model.
+// The activity (messages) is used when entries are
+// not specified.
 func (r *TaskReport) Model() (m *model.TaskReport) {
 	m = &model.TaskReport{
 		Status:    r.Status,
@@ -435,12 +951,214 @@ func (r *TaskReport) Model() (m *model.TaskReport) {
 		Completed: r.Completed,
 		TaskID:    r.TaskID,
 	}
-	if r.Activity == nil {
-		r.Activity = []string{}
+	entries := r.Entries
+	if len(entries) == 0 {
+		entries = []TaskReportEntry{}
+		for _, message := range r.Activity {
+			entries = append(
+				entries,
+				TaskReportEntry{
+					Severity: SeverityInfo,
+					Message:  message,
+				})
+		}
+	}
+	m.Activity, _ = json.Marshal(entries)
+	if r.Errors != nil {
+		m.Errors, _ = json.Marshal(r.Errors)
+	}
+	if r.Output != nil {
+		m.Output, _ = json.Marshal(r.Output)
+	}
+	if r.Result != nil {
+		m.Result, _ = json.Marshal(r.Result)
 	}
-	_ = json.Unmarshal(m.Activity, &r.Activity)
-	m.Activity, _ = json.Marshal(r.Activity)
 	m.ID = r.ID
 
 	return
 }
+
+//
+// Report entry severity.
+const (
+	SeverityInfo    = "Info"
+	SeverityWarning = "Warning"
+	SeverityError   = "Error"
+)
+
+//
+// TaskReportEntry structured report entry.
+type TaskReportEntry struct {
+	Time     *time.Time             `json:"time,omitempty"`
+	Severity string                 `json:"severity"`
+	Message  string                 `json:"message"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+}
+
+//
+// UnmarshalJSON accepts an entry or a message (string).
+// Activity stored as strings remains readable.
+func (r *TaskReportEntry) UnmarshalJSON(b []byte) (err error) {
+	var message string
+	if json.Unmarshal(b, &message) == nil {
+		*r = TaskReportEntry{
+			Severity: SeverityInfo,
+			Message:  message,
+		}
+		return
+	}
+	type entry TaskReportEntry
+	err = json.Unmarshal(b, (*entry)(r))
+	return
+}
+
+//
+// TaskFilter filters the task list.
+type TaskFilter struct {
+	Locator     string
+	Status      []string
+	Addon       string
+	Name        string
+	Application uint
+	Batch       uint
+	Created     TimeRange
+	Started     TimeRange
+	Terminated  TimeRange
+}
+
+//
+// With updates the filter with the query params.
+// The status may be specified multiple times or as a
+// comma separated list. Pending may be specified by name.
+func (r *TaskFilter) With(ctx *gin.Context) (err error) {
+	r.Locator = ctx.Query(LocatorParam)
+	r.Addon = ctx.Query(AddonParam)
+	r.Name = ctx.Query(NameParam)
+	for _, param := range ctx.QueryArray(StatusParam) {
+		for _, status := range strings.Split(param, ",") {
+			status = strings.TrimSpace(status)
+			if status == "Pending" {
+				status = task.Pending
+			}
+			r.Status = append(r.Status, status)
+		}
+	}
+	r.Application, err = r.id(ctx, ApplicationParam)
+	if err != nil {
+		return
+	}
+	r.Batch, err = r.id(ctx, BatchParam)
+	if err != nil {
+		return
+	}
+	ranges := []struct {
+		timeRange *TimeRange
+		after     string
+		before    string
+	}{
+		{&r.Created, CreatedAfterParam, CreatedBeforeParam},
+		{&r.Started, StartedAfterParam, StartedBeforeParam},
+		{&r.Terminated, TerminatedAfterParam, TerminatedBeforeParam},
+	}
+	for _, p := range ranges {
+		p.timeRange.After, err = r.time(ctx, p.after)
+		if err != nil {
+			return
+		}
+		p.timeRange.Before, err = r.time(ctx, p.before)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+//
+// apply the filter.
+func (r *TaskFilter) apply(db *gorm.DB) (tx *gorm.DB) {
+	tx = db
+	if r.Locator != "" {
+		tx = tx.Where("locator", r.Locator)
+	}
+	if len(r.Status) > 0 {
+		tx = tx.Where("status IN ?", r.Status)
+	}
+	if r.Addon != "" {
+		tx = tx.Where("addon", r.Addon)
+	}
+	if r.Name != "" {
+		tx = tx.Where("name LIKE ?", "%"+r.Name+"%")
+	}
+	if r.Application > 0 {
+		tx = tx.Where("applicationid", r.Application)
+	}
+	if r.Batch > 0 {
+		tx = tx.Where("batchid", r.Batch)
+	}
+	tx = r.Created.apply(tx, "createtime")
+	tx = r.Started.apply(tx, "started")
+	tx = r.Terminated.apply(tx, "terminated")
+	return
+}
+
+//
+// id parses the ID param.
+func (r *TaskFilter) id(ctx *gin.Context, name string) (id uint, err error) {
+	s := ctx.Query(name)
+	if s == "" {
+		return
+	}
+	n, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		err = fmt.Errorf("%s: must be an integer.", name)
+		return
+	}
+	id = uint(n)
+	return
+}
+
+//
+// time parses the time param.
+// The value is either an RFC3339 time or a duration
+// (ago) such as 24h.
+func (r *TaskFilter) time(ctx *gin.Context, name string) (t *time.Time, err error) {
+	s := ctx.Query(name)
+	if s == "" {
+		return
+	}
+	d, dErr := time.ParseDuration(s)
+	if dErr == nil {
+		mark := time.Now().Add(-d)
+		t = &mark
+		return
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		err = fmt.Errorf("%s: must be RFC3339 time or duration.", name)
+		return
+	}
+	parsed = parsed.Local()
+	t = &parsed
+	return
+}
+
+//
+// TimeRange time range filter.
+type TimeRange struct {
+	After  *time.Time
+	Before *time.Time
+}
+
+//
+// apply the filter.
+func (r *TimeRange) apply(db *gorm.DB, column string) (tx *gorm.DB) {
+	tx = db
+	if r.After != nil {
+		tx = tx.Where(column+" > ?", *r.After)
+	}
+	if r.Before != nil {
+		tx = tx.Where(column+" < ?", *r.Before)
+	}
+	return
+}
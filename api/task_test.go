@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle-hub/model"
+	"github.com/konveyor/tackle-hub/task"
+	"github.com/onsi/gomega"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDependencies(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db := testDB(t)
+	// 1 <- 2 <- 3
+	for _, dependsOn := range [][]uint{nil, {1}, {2}} {
+		m := &model.Task{Name: "test", Addon: "test"}
+		m.DependsOn, _ = json.Marshal(dependsOn)
+		g.Expect(db.Create(m).Error).To(gomega.BeNil())
+	}
+	h := TaskHandler{}
+	h.DB = db
+	//
+	// Valid.
+	g.Expect(h.dependencies(0, nil, nil)).To(gomega.BeNil())
+	g.Expect(h.dependencies(0, []uint{1, 3}, nil)).To(gomega.BeNil())
+	g.Expect(h.dependencies(3, []uint{1}, nil)).To(gomega.BeNil())
+	g.Expect(h.dependencies(
+		0,
+		[]uint{1},
+		map[string]task.Input{
+			"in": {Task: 1, Key: "key"},
+		})).To(gomega.BeNil())
+	//
+	// Not found.
+	g.Expect(h.dependencies(0, []uint{1, 9}, nil)).ToNot(gomega.BeNil())
+	//
+	// Self.
+	g.Expect(h.dependencies(1, []uint{1}, nil)).ToNot(gomega.BeNil())
+	//
+	// Cycle: 1 -> 3 -> 2 -> 1.
+	g.Expect(h.dependencies(1, []uint{3}, nil)).ToNot(gomega.BeNil())
+	//
+	// Input task not a dependency.
+	g.Expect(h.dependencies(
+		0,
+		[]uint{2},
+		map[string]task.Input{
+			"in": {Task: 1, Key: "key"},
+		})).ToNot(gomega.BeNil())
+}
+
+func TestTaskFilter(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	filter := func(query string) (filter TaskFilter, err error) {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("GET", "/tasks?"+query, nil)
+		err = filter.With(ctx)
+		return
+	}
+	//
+	// Empty.
+	f, err := filter("")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(f).To(gomega.Equal(TaskFilter{}))
+	//
+	// Status (multiple and comma separated).
+	f, err = filter("status=Running,%20Failed&status=Pending")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(f.Status).To(gomega.Equal(
+		[]string{
+			task.Running,
+			task.Failed,
+			task.Pending,
+		}))
+	//
+	// Fields.
+	f, err = filter("locator=L&addon=A&name=N&application=4&batch=2")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(f.Locator).To(gomega.Equal("L"))
+	g.Expect(f.Addon).To(gomega.Equal("A"))
+	g.Expect(f.Name).To(gomega.Equal("N"))
+	g.Expect(f.Application).To(gomega.Equal(uint(4)))
+	g.Expect(f.Batch).To(gomega.Equal(uint(2)))
+	//
+	// Times: RFC3339 and duration (ago).
+	before := time.Now().Add(-time.Hour)
+	f, err = filter("createdAfter=2022-03-03T10:30:00Z&startedBefore=1h")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(f.Created.After).ToNot(gomega.BeNil())
+	g.Expect(f.Created.After.Equal(
+		time.Date(2022, 3, 3, 10, 30, 0, 0, time.UTC))).To(gomega.BeTrue())
+	g.Expect(f.Created.Before).To(gomega.BeNil())
+	g.Expect(f.Started.Before).ToNot(gomega.BeNil())
+	g.Expect(f.Started.Before.Before(before)).To(gomega.BeFalse())
+	g.Expect(f.Started.Before.After(time.Now().Add(-time.Hour))).To(gomega.BeFalse())
+	g.Expect(f.Terminated).To(gomega.Equal(TimeRange{}))
+	//
+	// Not valid.
+	for _, query := range []string{
+		"application=a",
+		"batch=-1",
+		"createdBefore=yesterday",
+		"terminatedAfter=2022-03-03",
+	} {
+		_, err = filter(query)
+		g.Expect(err).ToNot(gomega.BeNil(), query)
+	}
+}
+
+//
+// testDB returns a new (migrated) DB.
+func testDB(t *testing.T) (db *gorm.DB) {
+	g := gomega.NewGomegaWithT(t)
+	db, err := gorm.Open(
+		sqlite.Open(t.TempDir()+"/test.db"),
+		&gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+			NamingStrategy: &schema.NamingStrategy{
+				SingularTable: true,
+				NoLowerCase:   true,
+			},
+		})
+	g.Expect(err).To(gomega.BeNil())
+	err = db.AutoMigrate(model.All()...)
+	g.Expect(err).To(gomega.BeNil())
+	return
+}
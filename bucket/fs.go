@@ -0,0 +1,176 @@
+package bucket
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	pathlib "path"
+	"path/filepath"
+)
+
+//
+// FileSystem storage.
+// Buckets are directories in the (volume) root.
+type FileSystem struct {
+	// Root directory.
+	Root string
+}
+
+//
+// Create a bucket by name and return the root.
+func (r *FileSystem) Create(name string) (root string, err error) {
+	root = pathlib.Join(r.Root, name)
+	err = os.MkdirAll(root, 0777)
+	return
+}
+
+//
+// Delete the bucket (root) and content.
+func (r *FileSystem) Delete(root string) (err error) {
+	err = os.RemoveAll(root)
+	return
+}
+
+//
+// Stat the file or directory.
+func (r *FileSystem) Stat(root, path string) (info Info, err error) {
+	st, err := os.Stat(r.join(root, path))
+	if err != nil {
+		return
+	}
+	info = Info{
+		Path:     pathlib.Base(clean(path)),
+		Size:     st.Size(),
+		Modified: st.ModTime(),
+		Dir:      st.IsDir(),
+	}
+	return
+}
+
+//
+// List the content of the directory (recursive).
+func (r *FileSystem) List(root, path string) (list []Info, err error) {
+	dir := r.join(root, path)
+	err = filepath.WalkDir(
+		dir,
+		func(path string, entry fs.DirEntry, wErr error) (err error) {
+			if wErr != nil {
+				err = wErr
+				return
+			}
+			if path == dir {
+				return
+			}
+			if !entry.IsDir() && !entry.Type().IsRegular() {
+				return
+			}
+			st, err := entry.Info()
+			if err != nil {
+				return
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return
+			}
+			list = append(
+				list,
+				Info{
+					Path:     filepath.ToSlash(rel),
+					Size:     st.Size(),
+					Modified: st.ModTime(),
+					Dir:      st.IsDir(),
+				})
+			return
+		})
+	return
+}
+
+//
+// Get (open) the file.
+func (r *FileSystem) Get(root, path string) (object io.ReadSeekCloser, err error) {
+	file, err := os.Open(r.join(root, path))
+	if err != nil {
+		return
+	}
+	st, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return
+	}
+	if st.IsDir() {
+		_ = file.Close()
+		err = errors.New(path + ": is a directory.")
+		return
+	}
+	object = file
+	return
+}
+
+//
+// Put (write) the file.
+// The content is written to a temporary file which
+// then replaces the file.
+func (r *FileSystem) Put(root, path string, reader io.Reader) (err error) {
+	path = r.join(root, path)
+	if path == pathlib.Clean(root) {
+		err = errors.New("path: file path required.")
+		return
+	}
+	err = os.MkdirAll(pathlib.Dir(path), 0777)
+	if err != nil {
+		return
+	}
+	file, err := os.CreateTemp(pathlib.Dir(path), ".upload-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		return
+	}
+	err = file.Chmod(0666)
+	if err != nil {
+		return
+	}
+	err = os.Rename(file.Name(), path)
+	return
+}
+
+//
+// Remove the file or directory. When the path is
+// the root, the content is removed.
+func (r *FileSystem) Remove(root, path string) (err error) {
+	path = r.join(root, path)
+	_, err = os.Stat(path)
+	if err != nil {
+		return
+	}
+	if path != pathlib.Clean(root) {
+		err = os.RemoveAll(path)
+		return
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		err = os.RemoveAll(pathlib.Join(path, entry.Name()))
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//
+// join returns the (local) path rooted in the bucket.
+func (r *FileSystem) join(root, path string) string {
+	return pathlib.Join(root, clean(path))
+}
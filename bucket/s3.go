@@ -0,0 +1,588 @@
+package bucket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	pathlib "path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// S3 (compatible) storage.
+// Buckets are key prefixes in the (S3) bucket. Requests use
+// path-style addressing and are signed (AWS Signature V4).
+// Directories are implied by the keys.
+type S3 struct {
+	// Endpoint URL.
+	Endpoint string
+	// Region.
+	Region string
+	// Bucket (S3) name.
+	Bucket string
+	// AccessKey (ID).
+	AccessKey string
+	// SecretKey.
+	SecretKey string
+	// HTTP client.
+	Client *http.Client
+}
+
+//
+// Create a bucket by name and return the root.
+// Nothing is stored until content is put.
+func (r *S3) Create(name string) (root string, err error) {
+	root = strings.Trim(name, "/")
+	return
+}
+
+//
+// Delete the bucket (root) and content.
+func (r *S3) Delete(root string) (err error) {
+	keys, err := r.keys(r.key(root, "/") + "/")
+	if err != nil {
+		return
+	}
+	err = r.deleteKeys(keys)
+	return
+}
+
+//
+// Stat the file or directory.
+// The root is always a directory.
+func (r *S3) Stat(root, path string) (info Info, err error) {
+	info.Path = pathlib.Base(clean(path))
+	if clean(path) == "/" {
+		info.Dir = true
+		return
+	}
+	key := r.key(root, path)
+	reply, err := r.send(http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return
+	}
+	_ = reply.Body.Close()
+	switch reply.StatusCode {
+	case http.StatusOK:
+		info.Size = reply.ContentLength
+		info.Modified, _ = http.ParseTime(reply.Header.Get("Last-Modified"))
+		return
+	case http.StatusNotFound:
+	default:
+		err = r.error(http.MethodHead, key, reply)
+		return
+	}
+	found, err := r.list(key+"/", "", 1)
+	if err != nil {
+		return
+	}
+	if len(found.Contents) == 0 {
+		err = r.notFound(key)
+		return
+	}
+	info.Dir = true
+	return
+}
+
+//
+// List the content of the directory (recursive).
+// Directories are implied by the keys.
+func (r *S3) List(root, path string) (list []Info, err error) {
+	prefix := r.key(root, path) + "/"
+	token := ""
+	dirs := make(map[string]bool)
+	for {
+		var found *s3List
+		found, err = r.list(prefix, token, 1000)
+		if err != nil {
+			return
+		}
+		for _, object := range found.Contents {
+			rel := strings.TrimPrefix(object.Key, prefix)
+			for dir := pathlib.Dir(rel); dir != "."; dir = pathlib.Dir(dir) {
+				if dirs[dir] {
+					break
+				}
+				dirs[dir] = true
+				list = append(
+					list,
+					Info{
+						Path:     dir,
+						Modified: object.LastModified,
+						Dir:      true,
+					})
+			}
+			list = append(
+				list,
+				Info{
+					Path:     rel,
+					Size:     object.Size,
+					Modified: object.LastModified,
+				})
+		}
+		if !found.IsTruncated {
+			break
+		}
+		token = found.NextContinuationToken
+	}
+	sort.SliceStable(
+		list,
+		func(i, j int) bool {
+			return list[i].Path < list[j].Path
+		})
+	return
+}
+
+//
+// Get (open) the file.
+// The content is read using ranged requests so
+// the object may be seeked.
+func (r *S3) Get(root, path string) (object io.ReadSeekCloser, err error) {
+	info, err := r.Stat(root, path)
+	if err != nil {
+		return
+	}
+	if info.Dir {
+		err = errors.New(path + ": is a directory.")
+		return
+	}
+	object = &s3Object{
+		storage: r,
+		key:     r.key(root, path),
+		size:    info.Size,
+	}
+	return
+}
+
+//
+// Put (write) the file.
+// The content is spooled to a temporary file so that
+// the length and the (signed) hash are known.
+func (r *S3) Put(root, path string, reader io.Reader) (err error) {
+	if clean(path) == "/" {
+		err = errors.New("path: file path required.")
+		return
+	}
+	file, err := os.CreateTemp("", "bucket-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(file, hash), reader)
+	if err != nil {
+		return
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return
+	}
+	key := r.key(root, path)
+	request := &s3Body{
+		reader: io.NopCloser(file),
+		length: n,
+		hash:   hex.EncodeToString(hash.Sum(nil)),
+	}
+	reply, err := r.send(http.MethodPut, key, nil, nil, request)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reply.Body.Close()
+	}()
+	if reply.StatusCode != http.StatusOK {
+		err = r.error(http.MethodPut, key, reply)
+	}
+	return
+}
+
+//
+// Remove the file or directory. When the path is
+// the root, the content is removed.
+func (r *S3) Remove(root, path string) (err error) {
+	info, err := r.Stat(root, path)
+	if err != nil {
+		return
+	}
+	key := r.key(root, path)
+	if !info.Dir {
+		err = r.deleteKeys([]string{key})
+		return
+	}
+	keys, err := r.keys(key + "/")
+	if err != nil {
+		return
+	}
+	err = r.deleteKeys(keys)
+	return
+}
+
+//
+// keys lists all keys with the prefix.
+func (r *S3) keys(prefix string) (keys []string, err error) {
+	token := ""
+	for {
+		var found *s3List
+		found, err = r.list(prefix, token, 1000)
+		if err != nil {
+			return
+		}
+		for _, object := range found.Contents {
+			keys = append(keys, object.Key)
+		}
+		if !found.IsTruncated {
+			break
+		}
+		token = found.NextContinuationToken
+	}
+	return
+}
+
+//
+// deleteKeys deletes the objects.
+func (r *S3) deleteKeys(keys []string) (err error) {
+	for _, key := range keys {
+		var reply *http.Response
+		reply, err = r.send(http.MethodDelete, key, nil, nil, nil)
+		if err != nil {
+			return
+		}
+		_ = reply.Body.Close()
+		switch reply.StatusCode {
+		case http.StatusOK,
+			http.StatusNoContent,
+			http.StatusNotFound:
+		default:
+			err = r.error(http.MethodDelete, key, reply)
+			return
+		}
+	}
+	return
+}
+
+//
+// list objects (ListObjectsV2) by prefix.
+func (r *S3) list(prefix, token string, max int) (found *s3List, err error) {
+	query := url.Values{}
+	query.Set("list-type", "2")
+	query.Set("prefix", prefix)
+	query.Set("max-keys", strconv.Itoa(max))
+	if token != "" {
+		query.Set("continuation-token", token)
+	}
+	reply, err := r.send(http.MethodGet, "", query, nil, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reply.Body.Close()
+	}()
+	if reply.StatusCode != http.StatusOK {
+		err = r.error(http.MethodGet, prefix, reply)
+		return
+	}
+	found = &s3List{}
+	err = xml.NewDecoder(reply.Body).Decode(found)
+	return
+}
+
+//
+// send a signed request.
+// The key is empty for (S3) bucket requests.
+func (r *S3) send(method, key string, query url.Values, header http.Header, body *s3Body) (reply *http.Response, err error) {
+	endpoint, err := url.Parse(r.Endpoint)
+	if err != nil {
+		return
+	}
+	path := "/" + r.Bucket
+	if key != "" {
+		path += "/" + key
+	}
+	u := &url.URL{
+		Scheme:   endpoint.Scheme,
+		Host:     endpoint.Host,
+		Path:     path,
+		RawPath:  uriEncode(path, false),
+		RawQuery: canonicalQuery(query),
+	}
+	request := &http.Request{
+		Method: method,
+		URL:    u,
+		Host:   u.Host,
+		Header: http.Header{},
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	hash := emptyHash
+	if body != nil {
+		request.Body = body.reader
+		request.ContentLength = body.length
+		hash = body.hash
+	}
+	r.sign(request, hash, time.Now())
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	reply, err = client.Do(request)
+	return
+}
+
+//
+// sign the request (AWS Signature V4).
+func (r *S3) sign(request *http.Request, hash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", hash)
+	headers := map[string]string{
+		"host": request.Host,
+	}
+	for name, values := range request.Header {
+		name = strings.ToLower(name)
+		switch {
+		case name == "range",
+			name == "content-type",
+			strings.HasPrefix(name, "x-amz-"):
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonicalHeaders := ""
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonical := strings.Join(
+		[]string{
+			request.Method,
+			request.URL.EscapedPath(),
+			request.URL.RawQuery,
+			canonicalHeaders,
+			signedHeaders,
+			hash,
+		},
+		"\n")
+	scope := date + "/" + r.Region + "/s3/aws4_request"
+	digest := sha256.Sum256([]byte(canonical))
+	toSign := strings.Join(
+		[]string{
+			"AWS4-HMAC-SHA256",
+			amzDate,
+			scope,
+			hex.EncodeToString(digest[:]),
+		},
+		"\n")
+	key := hmacSHA256([]byte("AWS4"+r.SecretKey), date)
+	key = hmacSHA256(key, r.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	request.Header.Set(
+		"Authorization",
+		"AWS4-HMAC-SHA256 "+
+			"Credential="+r.AccessKey+"/"+scope+","+
+			"SignedHeaders="+signedHeaders+","+
+			"Signature="+signature)
+}
+
+//
+// key returns the object key for the path.
+func (r *S3) key(root, path string) (key string) {
+	key = strings.Trim(root, "/") + clean(path)
+	key = strings.TrimSuffix(key, "/")
+	return
+}
+
+//
+// error returns an error for the (unexpected) reply.
+func (r *S3) error(method, key string, reply *http.Response) (err error) {
+	if reply.StatusCode == http.StatusNotFound {
+		err = r.notFound(key)
+		return
+	}
+	content := struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}{}
+	_ = xml.NewDecoder(reply.Body).Decode(&content)
+	err = fmt.Errorf(
+		"s3: %s %s: [%d] %s %s",
+		method,
+		key,
+		reply.StatusCode,
+		content.Code,
+		content.Message)
+	return
+}
+
+//
+// notFound returns a not found error.
+func (r *S3) notFound(key string) error {
+	return fmt.Errorf("s3: %s: %w", key, os.ErrNotExist)
+}
+
+//
+// s3Body request body.
+type s3Body struct {
+	reader io.ReadCloser
+	length int64
+	hash   string
+}
+
+//
+// s3List ListObjectsV2 result.
+type s3List struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+//
+// s3Object (seekable) object reader.
+// Content is read using ranged requests from the offset.
+type s3Object struct {
+	storage *S3
+	key     string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+//
+// Read content.
+func (r *s3Object) Read(b []byte) (n int, err error) {
+	if r.offset >= r.size {
+		err = io.EOF
+		return
+	}
+	if r.body == nil {
+		header := http.Header{}
+		header.Set("Range", "bytes="+strconv.FormatInt(r.offset, 10)+"-")
+		var reply *http.Response
+		reply, err = r.storage.send(http.MethodGet, r.key, nil, header, nil)
+		if err != nil {
+			return
+		}
+		switch reply.StatusCode {
+		case http.StatusOK,
+			http.StatusPartialContent:
+			r.body = reply.Body
+		default:
+			err = r.storage.error(http.MethodGet, r.key, reply)
+			_ = reply.Body.Close()
+			return
+		}
+	}
+	n, err = r.body.Read(b)
+	r.offset += int64(n)
+	return
+}
+
+//
+// Seek to the offset.
+func (r *s3Object) Seek(offset int64, whence int) (n int64, err error) {
+	switch whence {
+	case io.SeekStart:
+		n = offset
+	case io.SeekCurrent:
+		n = r.offset + offset
+	case io.SeekEnd:
+		n = r.size + offset
+	}
+	if n < 0 {
+		err = errors.New("seek: offset not valid.")
+		return
+	}
+	if n != r.offset {
+		_ = r.Close()
+		r.offset = n
+	}
+	return
+}
+
+//
+// Close the object.
+func (r *s3Object) Close() (err error) {
+	if r.body != nil {
+		err = r.body.Close()
+		r.body = nil
+	}
+	return
+}
+
+//
+// emptyHash is the hash of an empty payload.
+const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+//
+// hmacSHA256 returns the HMAC (SHA256) of the data.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+//
+// canonicalQuery returns the canonical (sorted, encoded) query.
+func canonicalQuery(query url.Values) (s string) {
+	names := []string{}
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := []string{}
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(
+				parts,
+				uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	s = strings.Join(parts, "&")
+	return
+}
+
+//
+// uriEncode encodes all but the unreserved characters.
+// The slash is encoded when specified.
+func uriEncode(s string, slash bool) string {
+	b := strings.Builder{}
+	for _, c := range []byte(s) {
+		switch {
+		case c >= 'A' && c <= 'Z',
+			c >= 'a' && c <= 'z',
+			c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !slash:
+			b.WriteByte(c)
+		default:
+			b.WriteString(fmt.Sprintf("%%%02X", c))
+		}
+	}
+	return b.String()
+}
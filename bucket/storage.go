@@ -0,0 +1,190 @@
+package bucket
+
+import (
+	tarlib "archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/konveyor/tackle-hub/settings"
+	"io"
+	pathlib "path"
+	"time"
+)
+
+var Settings = &settings.Settings
+
+//
+// Default storage (configured by settings).
+var Default Storage
+
+//
+// Storage for bucket content.
+// The root is the bucket location (model.Bucket.Path) returned
+// by Create(). Paths are slash separated and relative to the
+// root. A path that does not exist is reported with an
+// error that wraps os.ErrNotExist.
+type Storage interface {
+	// Create a bucket by name and return the root.
+	Create(name string) (root string, err error)
+	// Delete the bucket (root) and content.
+	Delete(root string) (err error)
+	// Stat the file or directory.
+	Stat(root, path string) (info Info, err error)
+	// List the content of the directory (recursive).
+	// Paths are relative to the directory.
+	List(root, path string) (list []Info, err error)
+	// Get (open) the file.
+	Get(root, path string) (object io.ReadSeekCloser, err error)
+	// Put (write) the file.
+	Put(root, path string, reader io.Reader) (err error)
+	// Remove the file or directory. When the path is
+	// the root, the content is removed.
+	Remove(root, path string) (err error)
+}
+
+//
+// Info describes a file or directory.
+type Info struct {
+	// Path (relative).
+	Path string
+	// Size (bytes).
+	Size int64
+	// Modified time.
+	Modified time.Time
+	// Dir indicates a directory.
+	Dir bool
+}
+
+//
+// Configure the default storage.
+func Configure() (err error) {
+	Default, err = New()
+	return
+}
+
+//
+// New returns the storage selected by settings.
+func New() (storage Storage, err error) {
+	bucket := &Settings.Hub.Bucket
+	switch bucket.Storage {
+	case settings.BucketStorageFS:
+		storage = &FileSystem{
+			Root: bucket.Path,
+		}
+	case settings.BucketStorageS3:
+		if bucket.S3.Endpoint == "" || bucket.S3.Bucket == "" {
+			err = errors.New("bucket: s3 endpoint and bucket required.")
+			return
+		}
+		storage = &S3{
+			Endpoint:  bucket.S3.Endpoint,
+			Region:    bucket.S3.Region,
+			Bucket:    bucket.S3.Bucket,
+			AccessKey: bucket.S3.AccessKey,
+			SecretKey: bucket.S3.SecretKey,
+		}
+	default:
+		err = fmt.Errorf("bucket: storage '%s' not supported.", bucket.Storage)
+	}
+
+	return
+}
+
+//
+// Archive writes a (gzip) tar archive of the directory.
+func Archive(storage Storage, root, path string, output io.Writer) (err error) {
+	list, err := storage.List(root, path)
+	if err != nil {
+		return
+	}
+	zipWriter := gzip.NewWriter(output)
+	tarWriter := tarlib.NewWriter(zipWriter)
+	for _, info := range list {
+		header := &tarlib.Header{
+			Name:    info.Path,
+			ModTime: info.Modified,
+		}
+		if info.Dir {
+			header.Typeflag = tarlib.TypeDir
+			header.Name += "/"
+			header.Mode = 0755
+			err = tarWriter.WriteHeader(header)
+			if err != nil {
+				return
+			}
+			continue
+		}
+		header.Typeflag = tarlib.TypeReg
+		header.Mode = 0644
+		header.Size = info.Size
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return
+		}
+		err = copyObject(storage, root, pathlib.Join(path, info.Path), tarWriter)
+		if err != nil {
+			return
+		}
+	}
+	err = tarWriter.Close()
+	if err != nil {
+		return
+	}
+	err = zipWriter.Close()
+	return
+}
+
+//
+// Expand a (gzip) tar archive into the directory.
+// Entry names are rooted in the directory; entries other
+// than regular files are ignored.
+func Expand(storage Storage, root, path string, input io.Reader) (err error) {
+	zipReader, err := gzip.NewReader(input)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = zipReader.Close()
+	}()
+	tarReader := tarlib.NewReader(zipReader)
+	for {
+		var header *tarlib.Header
+		header, err = tarReader.Next()
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			break
+		}
+		if header.Typeflag != tarlib.TypeReg {
+			continue
+		}
+		name := pathlib.Clean("/" + header.Name)
+		err = storage.Put(root, pathlib.Join(path, name), tarReader)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+//
+// copyObject copies the file content to the writer.
+func copyObject(storage Storage, root, path string, writer io.Writer) (err error) {
+	object, err := storage.Get(root, path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = object.Close()
+	}()
+	_, err = io.Copy(writer, object)
+	return
+}
+
+//
+// clean returns the path rooted at "/".
+func clean(path string) string {
+	return pathlib.Clean("/" + path)
+}
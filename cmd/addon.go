@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/konveyor/tackle-hub/api"
+	"github.com/konveyor/tackle-hub/bucket"
+	"github.com/konveyor/tackle-hub/model"
+	"github.com/konveyor/tackle-hub/settings"
+	"github.com/konveyor/tackle-hub/task"
+	"gorm.io/gorm"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	pathlib "path"
+	"strings"
+	"time"
+)
+
+//
+// AddonRunner runs an addon as a local process (without k8s)
+// for addon development. An in-process hub API is started on
+// the (SQLite) DB; a task is created and the task secret
+// is written to the working directory. The addon is run with
+// the env set by the task manager. When the addon exits, the
+// task report and the bucket contents are printed.
+// Usage: hub addon run [options] -- <command> [args]
+type AddonRunner struct {
+	// DB
+	DB *gorm.DB
+	// Addon (name).
+	Addon string
+	// Data (task).
+	Data map[string]interface{}
+	// WorkingDir for the addon.
+	WorkingDir string
+	// Command (and args) to run.
+	Command []string
+	// hub URL.
+	hubURL string
+	// task created.
+	task *model.Task
+	// task token.
+	token string
+}
+
+//
+// Parse the command line.
+func (r *AddonRunner) Parse(args []string) (err error) {
+	flags := flag.NewFlagSet("addon run", flag.ContinueOnError)
+	data := flags.String("data", "{}", "Task data (JSON) or @path.")
+	appId := flags.Uint("application", 0, "Application ID (data.application).")
+	flags.StringVar(&r.Addon, "addon", "local", "Addon name.")
+	flags.StringVar(&r.WorkingDir, "workdir", "", "Working directory (default: temporary).")
+	err = flags.Parse(args)
+	if err != nil {
+		return
+	}
+	r.Command = flags.Args()
+	if len(r.Command) == 0 {
+		err = errors.New("addon command required.")
+		return
+	}
+	content := []byte(*data)
+	if strings.HasPrefix(*data, "@") {
+		content, err = os.ReadFile((*data)[1:])
+		if err != nil {
+			return
+		}
+	}
+	r.Data = make(map[string]interface{})
+	err = json.Unmarshal(content, &r.Data)
+	if err != nil {
+		err = fmt.Errorf("data: %w", err)
+		return
+	}
+	if *appId > 0 {
+		r.Data["application"] = *appId
+	}
+	if r.WorkingDir == "" {
+		r.WorkingDir, err = os.MkdirTemp("", "addon")
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+//
+// Run the addon.
+func (r *AddonRunner) Run() (err error) {
+	err = r.serve()
+	if err != nil {
+		return
+	}
+	err = r.create()
+	if err != nil {
+		return
+	}
+	secretPath, err := r.secret()
+	if err != nil {
+		return
+	}
+	env, err := r.env(secretPath)
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(r.Command[0], r.Command[1:]...)
+	cmd.Dir = r.WorkingDir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	runErr := cmd.Run()
+	err = r.terminate(runErr)
+	if err != nil {
+		return
+	}
+	err = r.print()
+	if err != nil {
+		return
+	}
+	err = runErr
+	return
+}
+
+//
+// serve the hub API (without k8s) on a local port.
+func (r *AddonRunner) serve() (err error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return
+	}
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(gin.Recovery())
+	auth := &api.TaskAuth{DB: r.DB}
+	router.Use(auth.Handler)
+	for _, h := range api.All() {
+		h.With(r.DB, nil, nil)
+		h.AddRoutes(router)
+	}
+	go func() {
+		_ = http.Serve(listener, router)
+	}()
+	r.hubURL = "http://" + listener.Addr().String()
+	log.Info("Hub API started.", "url", r.hubURL)
+	return
+}
+
+//
+// create the (running) task.
+func (r *AddonRunner) create() (err error) {
+	token, hash, err := task.NewToken()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	m := &model.Task{
+		Name:    r.Addon,
+		Addon:   r.Addon,
+		Image:   r.Command[0],
+		Status:  task.Running,
+		Started: &now,
+		Token:   hash,
+	}
+	m.Data, _ = json.Marshal(r.Data)
+	m.SetApplication()
+	result := r.DB.Create(m)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	r.task = m
+	r.token = token
+	log.Info("Task created.", "id", m.ID)
+	return
+}
+
+//
+// secret writes the task secret.
+func (r *AddonRunner) secret() (path string, err error) {
+	secret := task.Secret{}
+	secret.Hub.Task = r.task.ID
+	secret.Hub.Token = r.token
+	secret.Addon = r.Data
+	content, _ := json.Marshal(secret)
+	path = pathlib.Join(r.WorkingDir, "secret.json")
+	err = os.WriteFile(path, content, 0600)
+	return
+}
+
+//
+// env builds the addon env.
+// Same as the task (job) container.
+func (r *AddonRunner) env(secretPath string) (env []string, err error) {
+	env = []string{
+		settings.EnvBucketPath + "=" + Settings.Hub.Bucket.Path,
+		settings.EnvHubBaseURL + "=" + r.hubURL,
+		settings.EnvAddonSecretPath + "=" + secretPath,
+		settings.EnvWorkingDirPath + "=" + r.WorkingDir,
+	}
+	proxy := task.ProxyEnv{DB: r.DB, HubURL: r.hubURL}
+	proxyEnv, err := proxy.Build()
+	if err != nil {
+		return
+	}
+	for name, value := range proxyEnv {
+		env = append(env, name+"="+value)
+	}
+	return
+}
+
+//
+// terminate the task.
+// The status reported by the addon is used when terminal.
+// Otherwise, the status is based on the exit status.
+func (r *AddonRunner) terminate(runErr error) (err error) {
+	m := r.task
+	report := &model.TaskReport{}
+	result := r.DB.Limit(1).Find(report, "taskid = ?", m.ID)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	switch report.Status {
+	case task.Succeeded, task.Failed:
+		m.Status = report.Status
+		m.Error = report.Error
+	default:
+		if runErr != nil {
+			m.Status = task.Failed
+			m.Error = runErr.Error()
+		} else {
+			m.Status = task.Succeeded
+		}
+	}
+	now := time.Now()
+	m.Terminated = &now
+	m.Token = ""
+	result = r.DB.Omit("Canceled").Save(m)
+	err = result.Error
+	return
+}
+
+//
+// print the task report and the bucket contents.
+func (r *AddonRunner) print() (err error) {
+	m := r.task
+	out := struct {
+		Task    uint                `json:"task"`
+		Status  string              `json:"status"`
+		Error   string              `json:"error,omitempty"`
+		Report  *api.TaskReport     `json:"report,omitempty"`
+		Buckets map[string][]string `json:"buckets"`
+	}{
+		Task:    m.ID,
+		Status:  m.Status,
+		Error:   m.Error,
+		Buckets: make(map[string][]string),
+	}
+	report := &model.TaskReport{}
+	result := r.DB.Limit(1).Find(report, "taskid = ?", m.ID)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	if result.RowsAffected > 0 {
+		out.Report = &api.TaskReport{}
+		out.Report.With(report)
+	}
+	var buckets []model.Bucket
+	db := r.DB
+	if m.ApplicationID != nil {
+		db = db.Where("applicationid = ?", *m.ApplicationID)
+	} else {
+		db = db.Where("createtime >= ?", *m.Started)
+	}
+	result = db.Find(&buckets)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	for i := range buckets {
+		m := &buckets[i]
+		files := []string{}
+		list, _ := bucket.Default.List(m.Path, "/")
+		for _, info := range list {
+			if !info.Dir {
+				files = append(files, info.Path)
+			}
+		}
+		out.Buckets[m.Name] = files
+	}
+	content, _ := json.MarshalIndent(out, "", "  ")
+	fmt.Println(string(content))
+	return
+}
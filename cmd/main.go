@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/konveyor/controller/pkg/logging"
 	"github.com/konveyor/tackle-hub/api"
+	"github.com/konveyor/tackle-hub/bucket"
 	"github.com/konveyor/tackle-hub/importer"
 	"github.com/konveyor/tackle-hub/k8s"
 	crd "github.com/konveyor/tackle-hub/k8s/api"
@@ -109,6 +110,26 @@ func buildScheme() (err error) {
 	return
 }
 
+//
+// addonRun runs an addon locally (hub addon run).
+func addonRun(args []string) (err error) {
+	runner := &AddonRunner{}
+	err = runner.Parse(args)
+	if err != nil {
+		return
+	}
+	err = bucket.Configure()
+	if err != nil {
+		return
+	}
+	runner.DB, err = Setup()
+	if err != nil {
+		return
+	}
+	err = runner.Run()
+	return
+}
+
 //
 // main.
 func main() {
@@ -120,10 +141,22 @@ func main() {
 		}
 	}()
 	syscall.Umask(0)
+	if len(os.Args) > 2 && os.Args[1] == "addon" && os.Args[2] == "run" {
+		err = addonRun(os.Args[3:])
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
 	err = buildScheme()
 	if err != nil {
 		return
 	}
+	err = bucket.Configure()
+	if err != nil {
+		return
+	}
 	client, err := k8s.NewClient()
 	if err != nil {
 		return
@@ -135,15 +168,36 @@ func main() {
 	router := gin.Default()
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+	auth := &api.TaskAuth{DB: db}
+	router.Use(auth.Handler)
+	clientSet, err := k8s.NewClientSet()
+	if err != nil {
+		return
+	}
 	for _, h := range api.All() {
-		h.With(db, client)
+		h.With(db, client, clientSet)
 		h.AddRoutes(router)
 	}
+	cache := k8s.NewCache(
+		clientSet,
+		Settings.Hub.Namespace,
+		task.TaskLabel)
 	taskManager := task.Manager{
+		Client:    client,
+		ClientSet: clientSet,
+		Cache:     cache,
+		DB:        db,
+	}
+	taskManager.Run(context.Background())
+	reaper := task.Reaper{
 		Client: client,
 		DB:     db,
 	}
-	taskManager.Run(context.Background())
+	reaper.Run(context.Background())
+	scheduler := task.Scheduler{
+		DB: db,
+	}
+	scheduler.Run(context.Background())
 	importManager := importer.Manager{
 		DB: db,
 	}
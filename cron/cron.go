@@ -0,0 +1,217 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// Macros.
+var Macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+//
+// field bounds.
+type bounds struct {
+	min, max uint
+}
+
+//
+// Field bounds.
+var (
+	minute = bounds{0, 59}
+	hour   = bounds{0, 23}
+	dom    = bounds{1, 31}
+	month  = bounds{1, 12}
+	dow    = bounds{0, 7}
+)
+
+//
+// Schedule a parsed cron expression.
+// Fields: minute hour day-of-month month day-of-week.
+type Schedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// Both day fields restricted.
+	// When true, a day matches either field.
+	either bool
+}
+
+//
+// Parse a (5 field) cron expression.
+// Each field supports: *, N, N-M, */S, N-M/S and comma
+// separated lists. Day-of-week 7 is Sunday. The @yearly,
+// @monthly, @weekly, @daily and @hourly macros are supported.
+func Parse(expression string) (s *Schedule, err error) {
+	expression = strings.TrimSpace(expression)
+	if macro, found := Macros[expression]; found {
+		expression = macro
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		err = fmt.Errorf("cron: '%s' must have 5 fields.", expression)
+		return
+	}
+	s = &Schedule{}
+	s.minute, err = parse(fields[0], minute)
+	if err != nil {
+		return
+	}
+	s.hour, err = parse(fields[1], hour)
+	if err != nil {
+		return
+	}
+	s.dom, err = parse(fields[2], dom)
+	if err != nil {
+		return
+	}
+	s.month, err = parse(fields[3], month)
+	if err != nil {
+		return
+	}
+	s.dow, err = parse(fields[4], dow)
+	if err != nil {
+		return
+	}
+	if s.has(s.dow, 7) {
+		s.dow |= 1
+	}
+	s.either = !strings.HasPrefix(fields[2], "*") &&
+		!strings.HasPrefix(fields[4], "*")
+	return
+}
+
+//
+// Next returns the first time (minute) after t matched
+// by the schedule. A zero time is returned when not
+// matched within 5 years.
+func (s *Schedule) Next(t time.Time) (next time.Time) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !s.has(s.month, uint(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.day(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.has(s.hour, uint(t.Hour())) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.has(s.minute, uint(t.Minute())) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		next = t
+		return
+	}
+
+	return
+}
+
+//
+// day returns true when the day is matched.
+func (s *Schedule) day(t time.Time) (matched bool) {
+	inDom := s.has(s.dom, uint(t.Day()))
+	inDow := s.has(s.dow, uint(t.Weekday()))
+	if s.either {
+		matched = inDom || inDow
+	} else {
+		matched = inDom && inDow
+	}
+	return
+}
+
+//
+// has returns true when the bit is set.
+func (s *Schedule) has(bits uint64, n uint) bool {
+	return bits&(1<<n) != 0
+}
+
+//
+// parse a field.
+func parse(field string, b bounds) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		var n uint64
+		n, err = parsePart(part, b)
+		if err != nil {
+			err = fmt.Errorf("cron: field '%s' not valid: %w", field, err)
+			return
+		}
+		bits |= n
+	}
+	return
+}
+
+//
+// parse a comma separated part of a field.
+func parsePart(part string, b bounds) (bits uint64, err error) {
+	first, last, step := b.min, b.max, uint(1)
+	rangeAndStep := strings.SplitN(part, "/", 2)
+	if len(rangeAndStep) == 2 {
+		step, err = number(rangeAndStep[1])
+		if err != nil {
+			return
+		}
+		if step == 0 {
+			err = fmt.Errorf("step must be > 0")
+			return
+		}
+	}
+	expr := rangeAndStep[0]
+	switch {
+	case expr == "*":
+	case strings.Contains(expr, "-"):
+		bound := strings.SplitN(expr, "-", 2)
+		first, err = number(bound[0])
+		if err != nil {
+			return
+		}
+		last, err = number(bound[1])
+		if err != nil {
+			return
+		}
+	default:
+		first, err = number(expr)
+		if err != nil {
+			return
+		}
+		if len(rangeAndStep) == 1 {
+			last = first
+		}
+	}
+	if first < b.min || last > b.max || first > last {
+		err = fmt.Errorf("'%s' must be within: %d-%d", part, b.min, b.max)
+		return
+	}
+	for n := first; n <= last; n += step {
+		bits |= 1 << n
+	}
+	return
+}
+
+//
+// number parses an unsigned integer.
+func number(s string) (n uint, err error) {
+	parsed, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return
+	}
+	n = uint(parsed)
+	return
+}
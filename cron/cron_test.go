@@ -0,0 +1,52 @@
+package cron
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// Thursday.
+	now := time.Date(2022, 3, 3, 10, 30, 15, 0, time.UTC)
+	cases := map[string]time.Time{
+		"* * * * *":         time.Date(2022, 3, 3, 10, 31, 0, 0, time.UTC),
+		"*/15 * * * *":      time.Date(2022, 3, 3, 10, 45, 0, 0, time.UTC),
+		"0 * * * *":         time.Date(2022, 3, 3, 11, 0, 0, 0, time.UTC),
+		"@daily":            time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC),
+		"0 2 * * 0":         time.Date(2022, 3, 6, 2, 0, 0, 0, time.UTC),
+		"0 2 * * 7":         time.Date(2022, 3, 6, 2, 0, 0, 0, time.UTC),
+		"0 2 * * 1-5":       time.Date(2022, 3, 4, 2, 0, 0, 0, time.UTC),
+		"0 0 1 * *":         time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":        time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		"0 0 15 * 1":        time.Date(2022, 3, 7, 0, 0, 0, 0, time.UTC),
+		"10,20 8-9/1 * * *": time.Date(2022, 3, 4, 8, 10, 0, 0, time.UTC),
+	}
+	for expression, expected := range cases {
+		s, err := Parse(expression)
+		g.Expect(err).To(gomega.BeNil())
+		g.Expect(s.Next(now)).To(gomega.Equal(expected), expression)
+	}
+}
+
+func TestParseFailed(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		_, err := Parse(expression)
+		g.Expect(err).ToNot(gomega.BeNil(), expression)
+	}
+}
@@ -0,0 +1,16 @@
+#!/bin/bash
+
+host="${HOST:-localhost:8080}"
+
+curl -X POST ${host}/batches -d \
+'{
+    "createUser": "tackle",
+    "addon": "test",
+    "data": {
+      "path": "/etc"
+    },
+    "applications": [1, 2],
+    "selector": {
+      "tags": [1]
+    }
+}' | jq -M .
@@ -0,0 +1,31 @@
+#!/bin/bash
+
+host="${HOST:-localhost:8080}"
+
+curl -X POST ${host}/pipelines -d \
+'{
+    "createUser": "tackle",
+    "name": "Test",
+    "ordered": true,
+    "data": {
+      "application": 1
+    },
+    "steps": [
+      {
+        "name": "list",
+        "addon": "test",
+        "data": {
+          "path": "/etc"
+        }
+      },
+      {
+        "name": "report",
+        "addon": "test",
+        "inputs": {
+          "bucket": "list.bucket"
+        }
+      }
+    ]
+}' | jq -M .
+
+curl -X POST ${host}/pipelines/1/runs | jq -M .
@@ -7,7 +7,8 @@ curl -X POST ${host}/proxies -d \
     "createUser": "tackle",
     "kind": "http",
     "host":"myhost",
-    "port": 80
+    "port": 80,
+    "excluded": ["localhost", ".svc.cluster.local"]
 }' | jq -M .
 
 curl -X POST ${host}/proxies -d \
@@ -15,5 +16,6 @@ curl -X POST ${host}/proxies -d \
     "createUser": "tackle",
     "kind": "https",
     "host":"myhost",
-    "port": 443
+    "port": 443,
+    "excluded": ["localhost", ".svc.cluster.local"]
 }' | jq -M .
@@ -0,0 +1,17 @@
+#!/bin/bash
+
+host="${HOST:-localhost:8080}"
+
+curl -X POST ${host}/schedules -d \
+'{
+    "createUser": "tackle",
+    "name": "weekly",
+    "cron": "0 2 * * 0",
+    "addon": "test",
+    "data": {
+      "path": "/etc"
+    },
+    "selector": {
+      "tags": [1]
+    }
+}' | jq -M .
@@ -5,7 +5,11 @@ host="${HOST:-localhost:8080}"
 # ID to update (default:1)
 id="${1:-1}"
 
-curl -X POST ${host}/tasks/${id}/report -d \
+# Task token (see: the task secret).
+token="${TOKEN}"
+
+curl -X POST ${host}/tasks/${id}/report \
+  -H "Authorization: Bearer ${token}" -d \
 '{
     "updateUser": "tackle",
     "status": "Running",
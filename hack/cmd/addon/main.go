@@ -28,7 +28,7 @@ var (
 //
 // main
 func main() {
-	addon.Run(func() (err error){
+	addon.Run(func() (err error) {
 		//
 		// Get the addon data associated with the task.
 		d := &Data{}
@@ -45,6 +45,12 @@ func main() {
 		if err != nil {
 			return
 		}
+		//
+		// Task update: Report the (structured) result.
+		addon.Result(
+			map[string]interface{}{
+				"files": len(paths),
+			})
 		return
 	})
 }
@@ -62,6 +68,7 @@ func ensureBucket(d *Data, paths []string) (err error) {
 	bucket, err := addon.Bucket.Ensure(d.Application, "Listing")
 	if err == nil {
 		addon.Activity("Using bucket: id=%d", bucket.ID)
+		addon.Output("bucket", bucket.ID)
 	} else {
 		return
 	}
@@ -76,12 +83,12 @@ func ensureBucket(d *Data, paths []string) (err error) {
 		return
 	}
 	//
-	// Write files.
+	// Upload files.
+	content := addon.Bucket.Content(bucket)
 	for _, p := range paths {
-		var b []byte
 		//
-		// Read file.
-		b, err = os.ReadFile(p)
+		// Check file.
+		_, err = os.ReadFile(p)
 		if err != nil {
 			if errors.Is(err, os.ErrPermission) {
 				continue
@@ -90,16 +97,11 @@ func ensureBucket(d *Data, paths []string) (err error) {
 		}
 		//
 		// Task update: The current addon activity.
-		target := pathlib.Join(
-			bucket.Path,
-			pathlib.Base(p))
 		addon.Activity("writing: %s", p)
 		//
-		// Write file.
-		err = os.WriteFile(
-			target,
-			b,
-			0644)
+		// Upload file.
+		name := pathlib.Base(p)
+		err = content.Put(p, name)
 		if err != nil {
 			return
 		}
@@ -110,49 +112,11 @@ func ensureBucket(d *Data, paths []string) (err error) {
 		addon.Increment()
 	}
 	//
-	// Build the index.
-	err = buildIndex(bucket)
-	if err != nil {
-		return
-	}
-	//
 	// Task update: update the current addon activity.
 	addon.Activity("done")
 	return
 }
 
-//
-// Build index.html
-func buildIndex(bucket *api.Bucket) (err error) {
-	addon.Activity("Building index.")
-	time.Sleep(time.Second)
-	dir := bucket.Path
-	path := pathlib.Join(dir, "index.html")
-	f, err := os.Create(path)
-	if err != nil {
-		return
-	}
-	defer func() {
-		_ = f.Close()
-	}()
-	body := []string{"<ul>"}
-	list, err := os.ReadDir(dir)
-	if err != nil {
-		return
-	}
-	for _, name := range list {
-		body = append(
-			body,
-			"<li><a href=\""+name.Name()+"\">"+name.Name()+"</a>")
-	}
-
-	body = append(body, "</ul>")
-
-	_, _ = f.WriteString(strings.Join(body, "\n"))
-
-	return
-}
-
 //
 // find files.
 func find(path string, max int) (paths []string, err error) {
@@ -0,0 +1,8 @@
+#!/bin/bash
+
+host="${HOST:-localhost:8080}"
+
+# ID to cancel (default:1)
+id="${1:-1}"
+
+curl -X PUT ${host}/tasks/${id}/cancel
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -27,6 +28,16 @@ type Mount struct {
 	Claim string `json:"claim"`
 }
 
+//
+// Retry policy.
+type Retry struct {
+	// Max number of attempts.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// Backoff (seconds) before the first retry.
+	// Doubled after each failed attempt.
+	Backoff int `json:"backoff,omitempty"`
+}
+
 //
 // AddonSpec defines the desired state of Addon
 type AddonSpec struct {
@@ -34,6 +45,26 @@ type AddonSpec struct {
 	Image string `json:"image"`
 	// Mounts optional.
 	Mounts []Mount `json:"mounts,omitempty"`
+	// Retry policy optional.
+	Retry *Retry `json:"retry,omitempty"`
+	// Timeout (seconds) default for tasks optional.
+	Timeout int `json:"timeout,omitempty"`
+	// Resources (container) optional.
+	Resources core.ResourceRequirements `json:"resources,omitempty"`
+	// Env (container) optional.
+	Env []core.EnvVar `json:"env,omitempty"`
+	// ImagePullPolicy (container) optional.
+	ImagePullPolicy core.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// ImagePullSecrets (pod) optional.
+	ImagePullSecrets []core.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// ServiceAccountName (pod) optional.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// SecurityContext (container) optional.
+	SecurityContext *core.SecurityContext `json:"securityContext,omitempty"`
+	// NodeSelector (pod) optional.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations (pod) optional.
+	Tolerations []core.Toleration `json:"tolerations,omitempty"`
 }
 
 //
@@ -1,6 +1,9 @@
 package k8s
 
 import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
@@ -17,3 +20,32 @@ func NewClient() (newClient client.Client, err error) {
 		})
 	return
 }
+
+//
+// NewClientSet builds new k8s client set.
+// The client set provides access to sub-resources
+// such as pod logs.
+func NewClientSet() (clientSet kubernetes.Interface, err error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return
+	}
+	clientSet, err = kubernetes.NewForConfig(cfg)
+	return
+}
+
+//
+// NewCache builds new k8s (informer) cache.
+// The informers are limited to the namespace and to
+// objects with the label (selector).
+func NewCache(clientSet kubernetes.Interface, namespace, selector string) (newCache informers.SharedInformerFactory) {
+	newCache = informers.NewSharedInformerFactoryWithOptions(
+		clientSet,
+		0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(
+			func(options *meta.ListOptions) {
+				options.LabelSelector = selector
+			}))
+	return
+}
@@ -0,0 +1,13 @@
+package model
+
+//
+// Batch a group of tasks submitted together.
+// A task is created for each application.
+type Batch struct {
+	Model
+	Addon    string `gorm:"index"`
+	Data     JSON
+	Selector JSON
+	Priority int
+	Tasks    []Task `gorm:"constraint:OnDelete:SET NULL"`
+}
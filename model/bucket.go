@@ -1,8 +1,8 @@
 package model
 
 import (
+	"github.com/konveyor/tackle-hub/bucket"
 	"gorm.io/gorm"
-	"os"
 )
 
 type Bucket struct {
@@ -13,6 +13,8 @@ type Bucket struct {
 }
 
 func (m *Bucket) AfterDelete(db *gorm.DB) (err error) {
-	err = os.RemoveAll(m.Path)
+	if bucket.Default != nil {
+		err = bucket.Default.Delete(m.Path)
+	}
 	return
 }
@@ -0,0 +1,23 @@
+package model
+
+//
+// Pipeline a multi-step workflow across addons.
+type Pipeline struct {
+	Model
+	Name        string `gorm:"index;unique;not null"`
+	Description string
+	Ordered     bool
+	Data        JSON
+	Steps       JSON
+	Runs        []PipelineRun `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+//
+// PipelineRun an execution of a pipeline.
+// Each step is executed by a task.
+type PipelineRun struct {
+	Model
+	PipelineID uint `gorm:"index"`
+	Pipeline   *Pipeline
+	Tasks      []Task `gorm:"constraint:OnDelete:SET NULL"`
+}
@@ -34,7 +34,13 @@ func All() []interface{} {
 		Dependency{},
 		Review{},
 		Identity{},
+		Pipeline{},
+		PipelineRun{},
+		Schedule{},
+		Batch{},
 		Task{},
+		TaskAttempt{},
+		TaskLog{},
 		TaskReport{},
 		Proxy{},
 	}
@@ -9,4 +9,5 @@ type Proxy struct {
 	Host       string `gorm:"not null"`
 	Port       int
 	IdentityID uint `gorm:"index"`
+	Excluded   JSON
 }
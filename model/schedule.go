@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+//
+// Schedule creates addon tasks as scheduled
+// by a cron expression.
+type Schedule struct {
+	Model
+	Name     string `gorm:"index;unique;not null"`
+	Cron     string `gorm:"not null"`
+	Addon    string `gorm:"not null"`
+	Data     JSON
+	Selector JSON
+	LastRun  *time.Time
+	NextRun  *time.Time
+	Tasks    []Task `gorm:"constraint:OnDelete:SET NULL"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -8,27 +9,82 @@ type TaskReport struct {
 	Model
 	Status    string
 	Error     string
+	Errors    JSON
 	Total     int
 	Completed int
 	Activity  JSON
+	Output    JSON
+	Result    JSON
 	TaskID    uint `gorm:"uniqueIndex"`
 	Task      *Task
 }
 
 type Task struct {
 	Model
-	Name       string `gorm:"index"`
-	Addon      string `gorm:"index"`
-	Locator    string `gorm:"index"`
-	Image      string
-	Isolated   bool
-	Data       JSON
+	Name          string `gorm:"index"`
+	Addon         string `gorm:"index"`
+	Locator       string `gorm:"index"`
+	Image         string
+	Priority      int `gorm:"index"`
+	Isolated      bool
+	DependsOn     JSON
+	Inputs        JSON
+	Data          JSON
+	Started       *time.Time
+	Terminated    *time.Time
+	Status        string `gorm:"index"`
+	Error         string
+	Events        JSON
+	Job           string
+	Token         string `gorm:"index"`
+	Canceled      bool   `gorm:"index"`
+	Timeout       int
+	PipelineRunID *uint `gorm:"index"`
+	ScheduleID    *uint `gorm:"index"`
+	ApplicationID *uint `gorm:"index"`
+	BatchID       *uint `gorm:"index"`
+	MaxAttempts   int
+	Backoff       int
+	RetryAfter    *time.Time
+	Attempts      []TaskAttempt `gorm:"constraint:OnDelete:CASCADE"`
+	Logs          []TaskLog     `gorm:"constraint:OnDelete:CASCADE"`
+	Report        *TaskReport   `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+//
+// TaskAttempt records an attempt to run a task.
+type TaskAttempt struct {
+	Model
+	Job        string
 	Started    *time.Time
 	Terminated *time.Time
 	Status     string
-	Error      string
-	Job        string
-	Report     *TaskReport `gorm:"constraint:OnDelete:CASCADE"`
+	Reason     string
+	TaskID     uint `gorm:"index"`
+	Task       *Task
+}
+
+//
+// TaskLog container log collected for a task.
+type TaskLog struct {
+	Model
+	Job       string
+	Pod       string
+	Container string
+	Content   string
+	TaskID    uint `gorm:"index"`
+	Task      *Task
+}
+
+//
+// SetApplication sets the application (ID) referenced
+// in the data. Tasks are selected by application.
+func (m *Task) SetApplication() {
+	data := struct {
+		Application *uint `json:"application"`
+	}{}
+	_ = json.Unmarshal(m.Data, &data)
+	m.ApplicationID = data.Application
 }
 
 func (m *Task) Reset() {
@@ -36,4 +92,9 @@ func (m *Task) Reset() {
 	m.Terminated = nil
 	m.Report = nil
 	m.Status = ""
+	m.Canceled = false
+	m.RetryAfter = nil
+	m.Attempts = nil
+	m.Logs = nil
+	m.Events = nil
 }
@@ -3,12 +3,15 @@ package settings
 import (
 	"net/url"
 	"os"
+	"strconv"
 )
 
 const (
 	EnvAddonSecretPath = "ADDON_SECRET_PATH"
 	EnvWorkingDirPath  = "ADDON_WORKINGDIR_PATH"
 	EnvHubBaseURL      = "HUB_BASE_URL"
+	EnvHubTimeout      = "HUB_TIMEOUT"
+	EnvHubRetries      = "HUB_RETRIES"
 )
 
 //
@@ -18,6 +21,12 @@ type Addon struct {
 	Hub struct {
 		// URL for the hub API.
 		URL string
+		// Timeout (seconds) for hub requests.
+		// Content transfers are limited only while connecting
+		// and waiting for the reply.
+		Timeout int
+		// Retries for failed hub requests.
+		Retries int
 	}
 	// Path.
 	Path struct {
@@ -38,6 +47,24 @@ func (r *Addon) Load() (err error) {
 	if err != nil {
 		panic(err)
 	}
+	s, found := os.LookupEnv(EnvHubTimeout)
+	if found {
+		r.Hub.Timeout, err = strconv.Atoi(s)
+		if err != nil {
+			return
+		}
+	} else {
+		r.Hub.Timeout = 60
+	}
+	s, found = os.LookupEnv(EnvHubRetries)
+	if found {
+		r.Hub.Retries, err = strconv.Atoi(s)
+		if err != nil {
+			return
+		}
+	} else {
+		r.Hub.Retries = 6
+	}
 	r.Path.Secret, found = os.LookupEnv(EnvAddonSecretPath)
 	if !found {
 		r.Path.Secret = "/tmp/secret.json"
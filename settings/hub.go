@@ -2,15 +2,33 @@ package settings
 
 import (
 	"os"
+	"strconv"
 )
 
 const (
-	EnvNamespace  = "NAMESPACE"
-	EnvDbPath     = "DB_PATH"
-	EnvDbSeedPath = "DB_SEED_PATH"
-	EnvBucketPath = "BUCKET_PATH"
-	EnvBucketPVC  = "BUCKET_PVC"
-	EnvPassphrase = "ENCRYPTION_PASSPHRASE"
+	EnvNamespace            = "NAMESPACE"
+	EnvDbPath               = "DB_PATH"
+	EnvDbSeedPath           = "DB_SEED_PATH"
+	EnvBucketPath           = "BUCKET_PATH"
+	EnvBucketPVC            = "BUCKET_PVC"
+	EnvBucketStorage        = "BUCKET_STORAGE"
+	EnvBucketS3Endpoint     = "BUCKET_S3_ENDPOINT"
+	EnvBucketS3Region       = "BUCKET_S3_REGION"
+	EnvBucketS3Bucket       = "BUCKET_S3_BUCKET"
+	EnvBucketS3AccessKey    = "BUCKET_S3_ACCESS_KEY"
+	EnvBucketS3SecretKey    = "BUCKET_S3_SECRET_KEY"
+	EnvPassphrase           = "ENCRYPTION_PASSPHRASE"
+	EnvTaskConcurrency      = "TASK_CONCURRENCY"
+	EnvTaskAddonConcurrency = "TASK_ADDON_CONCURRENCY"
+	EnvTaskResync           = "TASK_RESYNC"
+	EnvTaskRetention        = "TASK_RETENTION"
+)
+
+//
+// Bucket storage.
+const (
+	BucketStorageFS = "fs"
+	BucketStorageS3 = "s3"
 )
 
 type Hub struct {
@@ -23,13 +41,48 @@ type Hub struct {
 	}
 	// Bucket settings.
 	Bucket struct {
+		// Storage (fs|s3).
+		Storage string
+		// Path (fs) of the bucket volume.
 		Path string
-		PVC  string
+		// PVC (fs) mounted by addons.
+		PVC string
+		// S3 (compatible) storage.
+		S3 struct {
+			// Endpoint URL.
+			Endpoint string
+			// Region.
+			Region string
+			// Bucket (S3) name.
+			Bucket string
+			// AccessKey (ID).
+			AccessKey string
+			// SecretKey.
+			SecretKey string
+		}
 	}
 	// Encryption settings.
 	Encryption struct {
 		Passphrase string
 	}
+	// Task settings.
+	Task struct {
+		// Concurrency is the max number of running
+		// tasks (0=unlimited).
+		Concurrency int
+		// Resync (seconds) is the interval at which all
+		// running tasks are reconciled with their jobs.
+		Resync int
+		// Retention (seconds) is the period that the jobs
+		// and secrets of terminated tasks are retained.
+		Retention int
+		// Addon settings.
+		Addon struct {
+			// Concurrency is the max number of running
+			// tasks per addon (0=unlimited).
+			Concurrency int
+		}
+	}
 }
 
 func (r *Hub) Load() (err error) {
@@ -54,10 +107,54 @@ func (r *Hub) Load() (err error) {
 	if !found {
 		r.Bucket.PVC = "bucket"
 	}
+	r.Bucket.Storage, found = os.LookupEnv(EnvBucketStorage)
+	if !found {
+		r.Bucket.Storage = BucketStorageFS
+	}
+	r.Bucket.S3.Endpoint = os.Getenv(EnvBucketS3Endpoint)
+	r.Bucket.S3.Region, found = os.LookupEnv(EnvBucketS3Region)
+	if !found {
+		r.Bucket.S3.Region = "us-east-1"
+	}
+	r.Bucket.S3.Bucket = os.Getenv(EnvBucketS3Bucket)
+	r.Bucket.S3.AccessKey = os.Getenv(EnvBucketS3AccessKey)
+	r.Bucket.S3.SecretKey = os.Getenv(EnvBucketS3SecretKey)
 	r.Encryption.Passphrase, found = os.LookupEnv(EnvPassphrase)
 	if !found {
 		r.Encryption.Passphrase = "tackle"
 	}
+	s, found := os.LookupEnv(EnvTaskConcurrency)
+	if found {
+		r.Task.Concurrency, err = strconv.Atoi(s)
+		if err != nil {
+			return
+		}
+	}
+	s, found = os.LookupEnv(EnvTaskAddonConcurrency)
+	if found {
+		r.Task.Addon.Concurrency, err = strconv.Atoi(s)
+		if err != nil {
+			return
+		}
+	}
+	s, found = os.LookupEnv(EnvTaskResync)
+	if found {
+		r.Task.Resync, err = strconv.Atoi(s)
+		if err != nil {
+			return
+		}
+	} else {
+		r.Task.Resync = 60
+	}
+	s, found = os.LookupEnv(EnvTaskRetention)
+	if found {
+		r.Task.Retention, err = strconv.Atoi(s)
+		if err != nil {
+			return
+		}
+	} else {
+		r.Task.Retention = 259200 // 72 hours.
+	}
 
 	return
 }
@@ -0,0 +1,103 @@
+package task
+
+import (
+	"context"
+	core "k8s.io/api/core/v1"
+	"path"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"time"
+)
+
+//
+// EventLimit is the max number of events kept for a task.
+const EventLimit = 20
+
+//
+// Event is a k8s event reported for the job or its pods.
+type Event struct {
+	// Kind of the involved object.
+	Kind string `json:"kind"`
+	// Name of the involved object.
+	Name string `json:"name"`
+	// Type (Normal|Warning).
+	Type string `json:"type"`
+	// Reason (short).
+	Reason string `json:"reason"`
+	// Message (human readable).
+	Message string `json:"message"`
+	// Count of occurrences.
+	Count int32 `json:"count"`
+	// Last occurrence.
+	Last time.Time `json:"last"`
+}
+
+//
+// Events lists the k8s events for the job and its pods.
+type Events struct {
+	// k8s client.
+	Client client.Client
+}
+
+//
+// List the events (most recent last) for the job
+// and the pods. The job is: namespace/name.
+func (r *Events) List(job string, pods []core.Pod) (list []Event, err error) {
+	names := []string{path.Base(job)}
+	for i := range pods {
+		names = append(names, pods[i].Name)
+	}
+	for _, name := range names {
+		var found []Event
+		found, err = r.find(path.Dir(job), name)
+		if err != nil {
+			return
+		}
+		list = append(list, found...)
+	}
+	sort.SliceStable(
+		list,
+		func(i, j int) bool {
+			return list[i].Last.Before(list[j].Last)
+		})
+	if len(list) > EventLimit {
+		list = list[len(list)-EventLimit:]
+	}
+
+	return
+}
+
+//
+// find the events for the involved object.
+func (r *Events) find(namespace, name string) (list []Event, err error) {
+	options := client.InNamespace(namespace)
+	err = options.SetFieldSelector("involvedObject.name=" + name)
+	if err != nil {
+		return
+	}
+	found := &core.EventList{}
+	err = r.Client.List(context.TODO(), options, found)
+	if err != nil {
+		return
+	}
+	for i := range found.Items {
+		event := &found.Items[i]
+		last := event.LastTimestamp.Time
+		if last.IsZero() {
+			last = event.EventTime.Time
+		}
+		list = append(
+			list,
+			Event{
+				Kind:    event.InvolvedObject.Kind,
+				Name:    event.InvolvedObject.Name,
+				Type:    event.Type,
+				Reason:  event.Reason,
+				Message: event.Message,
+				Count:   event.Count,
+				Last:    last,
+			})
+	}
+
+	return
+}
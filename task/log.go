@@ -0,0 +1,131 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"github.com/konveyor/tackle-hub/model"
+	"io"
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"path"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+//
+// LogLimit is the max number of bytes collected
+// for each container.
+const LogLimit = int64(1 << 20)
+
+//
+// Log provides access to the container logs
+// of the pods created for a task job.
+type Log struct {
+	// k8s client.
+	Client client.Client
+	// k8s client set.
+	ClientSet kubernetes.Interface
+}
+
+//
+// Pods returns the pods created for the job.
+// The job is: namespace/name.
+func (r *Log) Pods(job string) (pods []core.Pod, err error) {
+	list := &core.PodList{}
+	err = r.Client.List(
+		context.TODO(),
+		client.InNamespace(path.Dir(job)).MatchingLabels(
+			map[string]string{
+				"job-name": path.Base(job),
+			}),
+		list)
+	if err != nil {
+		return
+	}
+	pods = list.Items
+	return
+}
+
+//
+// Copy the container logs for the job to the writer.
+// When follow=true, the logs are streamed until
+// the containers have terminated.
+func (r *Log) Copy(job string, follow bool, writer io.Writer) (err error) {
+	pods, err := r.Pods(job)
+	if err != nil {
+		return
+	}
+	for i := range pods {
+		pod := &pods[i]
+		for _, container := range pod.Spec.Containers {
+			_, err = fmt.Fprintln(writer, Header(pod.Name, container.Name))
+			if err != nil {
+				return
+			}
+			err = r.copy(pod, container.Name, follow, 0, writer)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+//
+// Collect the container logs for the job.
+func (r *Log) Collect(task *model.Task) (logs []model.TaskLog, err error) {
+	pods, err := r.Pods(task.Job)
+	if err != nil {
+		return
+	}
+	for i := range pods {
+		pod := &pods[i]
+		for _, container := range pod.Spec.Containers {
+			content := &strings.Builder{}
+			err = r.copy(pod, container.Name, false, LogLimit, content)
+			if err != nil {
+				return
+			}
+			logs = append(
+				logs,
+				model.TaskLog{
+					TaskID:    task.ID,
+					Job:       task.Job,
+					Pod:       pod.Name,
+					Container: container.Name,
+					Content:   content.String(),
+				})
+		}
+	}
+
+	return
+}
+
+//
+// copy a container log to the writer.
+func (r *Log) copy(pod *core.Pod, container string, follow bool, limit int64, writer io.Writer) (err error) {
+	options := &core.PodLogOptions{
+		Container: container,
+		Follow:    follow,
+	}
+	if limit > 0 {
+		options.LimitBytes = &limit
+	}
+	request := r.ClientSet.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options)
+	reader, err := request.Stream()
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	_, err = io.Copy(writer, reader)
+	return
+}
+
+//
+// Header for a container log.
+func Header(pod, container string) string {
+	return fmt.Sprintf("### %s/%s", pod, container)
+}
@@ -3,6 +3,8 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"github.com/konveyor/controller/pkg/logging"
 	crd "github.com/konveyor/tackle-hub/k8s/api/tackle/v1alpha1"
 	"github.com/konveyor/tackle-hub/model"
 	"github.com/konveyor/tackle-hub/settings"
@@ -11,22 +13,72 @@ import (
 	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
 	"path"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+//
+// TaskLabel (key) associates the jobs, pods and
+// secrets with the task.
+const TaskLabel = "Task"
+
+//
+// Retry backoff.
+const (
+	DefaultBackoff = time.Second * 10
+	MaxBackoff     = time.Hour
+)
+
 const (
 	Pending   = ""
 	Succeeded = "Succeeded"
 	Failed    = "Failed"
 	Running   = "Running"
 	Postponed = "Postponed"
+	Canceled  = "Canceled"
+	TimedOut  = "TimedOut"
+	Lost      = "Lost"
+	// Active.
+	Scheduling      = "Scheduling"
+	ImagePullFailed = "ImagePullFailed"
+	Evicted         = "Evicted"
 )
 
-var Settings = &settings.Settings
+//
+// Active statuses.
+// The job has been created and has not terminated. The
+// status reflects the phase of the (most recent) pod.
+var Active = []string{
+	Scheduling,
+	ImagePullFailed,
+	Running,
+	Evicted,
+}
+
+//
+// IsActive returns true when the status is active.
+func IsActive(status string) (active bool) {
+	for _, s := range Active {
+		if s == status {
+			active = true
+			break
+		}
+	}
+	return
+}
+
+var (
+	Settings = &settings.Settings
+	log      = logging.WithName("task")
+)
 
 //
 // Manager provides task management.
@@ -35,109 +87,603 @@ type Manager struct {
 	DB *gorm.DB
 	// k8s client.
 	Client client.Client
+	// k8s client set.
+	ClientSet kubernetes.Interface
+	// Cache (informer) optional.
+	// When specified, running tasks are reconciled as job
+	// and pod events are received. All running tasks are reconciled
+	// periodically (resync). Without the cache, all running
+	// tasks are reconciled each pass.
+	// The informers are limited to task jobs and pods.
+	Cache informers.SharedInformerFactory
+	// Task IDs queued by job events.
+	queued map[uint]bool
+	// Protect the queue.
+	mutex sync.Mutex
 }
 
 //
 // Run the manager.
 func (m *Manager) Run(ctx context.Context) {
+	err := m.watch(ctx)
+	if err != nil {
+		log.Error(err, "Job watch failed, polling.")
+		m.Cache = nil
+	}
 	go func() {
+		resync := time.Duration(Settings.Hub.Task.Resync) * time.Second
+		resynced := time.Time{}
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			default:
 				time.Sleep(time.Second)
-				_ = m.updateRunning()
+				_ = m.cancel()
+				if m.Cache == nil || time.Since(resynced) > resync {
+					m.dequeue()
+					_ = m.updateRunning()
+					resynced = time.Now()
+				} else {
+					_ = m.updateQueued()
+				}
 				_ = m.startPending()
 			}
 		}
 	}()
 }
 
+//
+// watch jobs and pods.
+// Job and pod events queue the associated task to be reconciled.
+// Pods are watched because the pod status (scheduling, image pull
+// and eviction) is not reflected in the job.
+func (m *Manager) watch(ctx context.Context) (err error) {
+	if m.Cache == nil {
+		return
+	}
+	m.queued = make(map[uint]bool)
+	for _, informer := range []toolscache.SharedIndexInformer{
+		m.Cache.Batch().V1().Jobs().Informer(),
+		m.Cache.Core().V1().Pods().Informer(),
+	} {
+		informer.AddEventHandler(
+			toolscache.ResourceEventHandlerFuncs{
+				AddFunc: func(object interface{}) {
+					m.enqueue(object)
+				},
+				UpdateFunc: func(_, object interface{}) {
+					m.enqueue(object)
+				},
+				DeleteFunc: func(object interface{}) {
+					m.enqueue(object)
+				},
+			})
+	}
+	m.Cache.Start(ctx.Done())
+	for kind, synced := range m.Cache.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			err = fmt.Errorf("%s cache not synchronized.", kind)
+			return
+		}
+	}
+
+	return
+}
+
+//
+// enqueue the task associated with the job or pod.
+// Both are labeled with the task ID.
+func (m *Manager) enqueue(object interface{}) {
+	if tombstone, cast := object.(toolscache.DeletedFinalStateUnknown); cast {
+		object = tombstone.Obj
+	}
+	owner, cast := object.(meta.Object)
+	if !cast {
+		return
+	}
+	id, err := strconv.Atoi(owner.GetLabels()[TaskLabel])
+	if err != nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.queued[uint(id)] = true
+}
+
+//
+// dequeue returns (and clears) the queued task IDs.
+func (m *Manager) dequeue() (ids []uint) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for id := range m.queued {
+		ids = append(ids, id)
+	}
+	m.queued = make(map[uint]bool)
+	return
+}
+
 //
 // startPending starts pending tasks.
+// Pending tasks are started by priority (highest first) and
+// then in the order created. A task is postponed when starting
+// it would violate isolation or exceed the concurrency limits.
+// Only pending (and postponed) tasks are fetched. Active tasks
+// are counted.
 func (m *Manager) startPending() (err error) {
 	list := []model.Task{}
-	result := m.DB.Find(
+	db := m.DB.Order("Priority DESC, CreateTime, ID")
+	result := db.Find(
 		&list,
 		"status IN ?",
 		[]string{
 			Pending,
-			Running,
 			Postponed,
 		})
 	if result.Error != nil {
 		err = result.Error
 		return
 	}
+	if len(list) == 0 {
+		return
+	}
+	active, err := m.active()
+	if err != nil {
+		return
+	}
 	for i := range list {
 		pending := &list[i]
+		status := pending.Status
 		task := Task{
 			client: m.Client,
+			db:     m.DB,
 			Task:   pending,
 		}
-		switch pending.Status {
-		case Pending,
-			Postponed:
-			if m.postpone(pending, list) {
-				pending.Status = Postponed
-				_ = m.DB.Save(pending)
-				continue
-			}
-			_ = task.Run()
-			_ = m.DB.Save(pending)
+		if pending.Canceled {
+			continue
+		}
+		if pending.RetryAfter != nil && time.Now().Before(*pending.RetryAfter) {
+			continue
+		}
+		ready, failed := m.dependencies(pending)
+		if failed == nil && ready {
+			failed = m.inputs(pending)
 		}
+		if failed != nil {
+			mark := time.Now()
+			pending.Status = Failed
+			pending.Terminated = &mark
+			pending.Error = failed.Error()
+			_ = m.save(pending, status)
+			continue
+		}
+		if !ready {
+			pending.Status = Postponed
+			_ = m.save(pending, status)
+			continue
+		}
+		if active.postpone(pending) {
+			pending.Status = Postponed
+			_ = m.save(pending, status)
+			continue
+		}
+		_ = task.Run()
+		if IsActive(pending.Status) {
+			active.add(pending)
+		}
+		if pending.Status == Failed {
+			_ = m.terminated(&task)
+		}
+		_ = m.save(pending, status)
 	}
 
 	return
 }
 
+//
+// active returns the (counted) active tasks.
+func (m *Manager) active() (active *Concurrency, err error) {
+	list := []struct {
+		Addon    string
+		Count    int
+		Isolated int
+	}{}
+	db := m.DB.Model(&model.Task{})
+	db = db.Select(
+		"Addon",
+		"COUNT(*) AS Count",
+		"SUM(Isolated) AS Isolated")
+	db = db.Where("status IN ?", Active)
+	db = db.Group("Addon")
+	result := db.Scan(&list)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	active = &Concurrency{
+		Addon: make(map[string]int),
+	}
+	for _, n := range list {
+		active.Total += n.Count
+		active.Addon[n.Addon] += n.Count
+		active.Isolated += n.Isolated
+	}
+	return
+}
+
 //
 // updateRunning tasks to reflect job status.
 func (m *Manager) updateRunning() (err error) {
 	list := []model.Task{}
-	result := m.DB.Find(&list, "status", Running)
+	result := m.DB.Find(&list, "status IN ?", Active)
 	if result.Error != nil {
 		err = result.Error
 		return
 	}
+	m.update(list)
+	return
+}
+
+//
+// updateQueued running tasks to reflect job status.
+func (m *Manager) updateQueued() (err error) {
+	ids := m.dequeue()
+	if len(ids) == 0 {
+		return
+	}
+	list := []model.Task{}
+	db := m.DB.Where("id IN ?", ids)
+	result := db.Find(&list, "status IN ?", Active)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	m.update(list)
+	return
+}
+
+//
+// update running tasks to reflect job status.
+func (m *Manager) update(list []model.Task) {
 	for _, running := range list {
+		status := running.Status
 		task := Task{
 			client: m.Client,
+			db:     m.DB,
 			Task:   &running,
 		}
 		err := task.Reflect()
+		if task.lost != nil {
+			_ = m.DB.Create(task.lost)
+		}
 		if err != nil {
 			continue
 		}
-		_ = m.DB.Save(&running)
+		switch running.Status {
+		case Succeeded,
+			Failed,
+			TimedOut:
+			_ = m.terminated(&task)
+		}
+		_ = m.save(&running, status)
+	}
+}
+
+//
+// terminated records the attempt and schedules a
+// retry of failed tasks as permitted by the retry policy.
+func (m *Manager) terminated(task *Task) (err error) {
+	_ = m.collectLogs(task.Task)
+	attempt := &model.TaskAttempt{
+		TaskID:     task.ID,
+		Job:        task.Job,
+		Started:    task.Started,
+		Terminated: task.Terminated,
+		Status:     task.Status,
+		Reason:     task.Error,
+	}
+	result := m.DB.Create(attempt)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	if task.Status != Failed {
+		return
+	}
+	var count int64
+	db := m.DB.Model(&model.TaskAttempt{})
+	db = db.Where("taskid", task.ID)
+	db = db.Where("status != ?", Lost)
+	result = db.Count(&count)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	maxAttempts, backoff := task.retryPolicy()
+	if int(count) >= maxAttempts {
+		return
+	}
+	for n := int64(1); n < count; n++ {
+		backoff *= 2
+		if backoff > MaxBackoff {
+			backoff = MaxBackoff
+			break
+		}
 	}
+	task.Retry(backoff)
+	log.Info(
+		"Task failed, retry scheduled.",
+		"id",
+		task.ID,
+		"attempt",
+		count,
+		"after",
+		backoff)
 
 	return
 }
 
 //
-// postpone task based on requested isolation.
-// An isolated task must run by itself and will cause all
-// other tasks to be postponed.
-func (m *Manager) postpone(pending *model.Task, list []model.Task) (found bool) {
+// cancel tasks for which cancellation has been requested.
+// The job and secret are deleted and the task is marked
+// as canceled. The task report is preserved.
+func (m *Manager) cancel() (err error) {
+	list := []model.Task{}
+	result := m.DB.Find(
+		&list,
+		"canceled = ? AND status IN ?",
+		true,
+		append(
+			[]string{
+				Pending,
+				Postponed,
+			},
+			Active...))
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
 	for i := range list {
-		task := &list[i]
-		if pending.ID == task.ID {
-			continue
+		canceled := &list[i]
+		status := canceled.Status
+		task := Task{
+			client: m.Client,
+			Task:   canceled,
+		}
+		if IsActive(canceled.Status) {
+			_ = m.collectLogs(canceled)
 		}
-		if pending.Status != Running {
+		err := task.Cancel()
+		if err != nil {
 			continue
 		}
-		if pending.Isolated || task.Isolated {
-			found = true
+		_ = m.save(canceled, status)
+	}
+
+	return
+}
+
+//
+// collectLogs collects and stores the container
+// logs of the pods created for the task job. The logs
+// are collected once for each job.
+func (m *Manager) collectLogs(task *model.Task) (err error) {
+	if task.Job == "" || m.ClientSet == nil {
+		return
+	}
+	var count int64
+	db := m.DB.Model(&model.TaskLog{})
+	db = db.Where("taskid = ? AND job = ?", task.ID, task.Job)
+	result := db.Count(&count)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	if count > 0 {
+		return
+	}
+	collector := Log{
+		Client:    m.Client,
+		ClientSet: m.ClientSet,
+	}
+	logs, err := collector.Collect(task)
+	if err != nil {
+		return
+	}
+	if len(logs) == 0 {
+		return
+	}
+	result = m.DB.Create(&logs)
+	err = result.Error
+	return
+}
+
+//
+// save the task.
+// The canceled flag is owned by the API and is never
+// written by the manager. A notification is published
+// when the status has changed. The token expires when
+// the task is no longer active.
+func (m *Manager) save(task *model.Task, status string) (err error) {
+	if !IsActive(task.Status) {
+		task.Token = ""
+	}
+	result := m.DB.Omit("Canceled").Save(task)
+	err = result.Error
+	if err != nil {
+		return
+	}
+	if task.Status != status {
+		Notifier.Publish(
+			Notification{
+				Kind:   Updated,
+				Task:   task.ID,
+				Status: task.Status,
+			})
+	}
+	return
+}
+
+//
+// dependencies determines whether all of the tasks on
+// which the pending task depends have succeeded.
+// A dependency that has failed, been canceled or deleted
+// is reported as failed.
+func (m *Manager) dependencies(pending *model.Task) (ready bool, failed error) {
+	ids := []uint{}
+	if len(pending.DependsOn) > 0 {
+		err := json.Unmarshal(pending.DependsOn, &ids)
+		if err != nil {
+			failed = fmt.Errorf("dependencies not valid: %w", err)
+			return
+		}
+	}
+	if len(ids) == 0 {
+		ready = true
+		return
+	}
+	list := []model.Task{}
+	result := m.DB.Find(&list, ids)
+	if result.Error != nil {
+		return
+	}
+	found := map[uint]*model.Task{}
+	for i := range list {
+		found[list[i].ID] = &list[i]
+	}
+	ready = true
+	for _, id := range ids {
+		if id == pending.ID {
+			failed = fmt.Errorf("dependency task (id=%d) cycle.", id)
+			ready = false
+			return
+		}
+		task, isFound := found[id]
+		if !isFound {
+			failed = fmt.Errorf("dependency task (id=%d) deleted.", id)
+			ready = false
 			return
 		}
+		switch task.Status {
+		case Succeeded:
+		case Failed,
+			TimedOut,
+			Canceled:
+			failed = fmt.Errorf(
+				"dependency task (id=%d) %s.",
+				id,
+				strings.ToLower(task.Status))
+			ready = false
+			return
+		default:
+			ready = false
+		}
 	}
 
 	return
 }
 
+//
+// inputs resolves the task inputs using the outputs
+// reported by other tasks. The resolved values are
+// set in the task data.
+func (m *Manager) inputs(pending *model.Task) (failed error) {
+	inputs := map[string]Input{}
+	if len(pending.Inputs) > 0 {
+		err := json.Unmarshal(pending.Inputs, &inputs)
+		if err != nil {
+			failed = fmt.Errorf("inputs not valid: %w", err)
+			return
+		}
+	}
+	if len(inputs) == 0 {
+		return
+	}
+	data := map[string]interface{}{}
+	if len(pending.Data) > 0 {
+		err := json.Unmarshal(pending.Data, &data)
+		if err != nil {
+			failed = fmt.Errorf("data must be an object: %w", err)
+			return
+		}
+	}
+	for key, input := range inputs {
+		report := &model.TaskReport{}
+		result := m.DB.First(report, "taskid", input.Task)
+		if result.Error != nil {
+			failed = fmt.Errorf(
+				"input '%s': task (id=%d) report not found.",
+				key,
+				input.Task)
+			return
+		}
+		output := map[string]interface{}{}
+		_ = json.Unmarshal(report.Output, &output)
+		v, found := output[input.Key]
+		if !found {
+			failed = fmt.Errorf(
+				"input '%s': task (id=%d) output '%s' not found.",
+				key,
+				input.Task,
+				input.Key)
+			return
+		}
+		data[key] = v
+	}
+	pending.Data, _ = json.Marshal(data)
+
+	return
+}
+
+//
+// Concurrency counts active tasks.
+type Concurrency struct {
+	// Total active.
+	Total int
+	// Active by addon.
+	Addon map[string]int
+	// Isolated active.
+	Isolated int
+}
+
+//
+// postpone task based on requested isolation and
+// concurrency limits.
+// An isolated task must run by itself and will cause all
+// other tasks to be postponed.
+func (r *Concurrency) postpone(pending *model.Task) (found bool) {
+	if r.Isolated > 0 {
+		found = true
+		return
+	}
+	if pending.Isolated && r.Total > 0 {
+		found = true
+		return
+	}
+	limit := Settings.Hub.Task.Concurrency
+	if limit > 0 && r.Total >= limit {
+		found = true
+		return
+	}
+	limit = Settings.Hub.Task.Addon.Concurrency
+	if limit > 0 && r.Addon[pending.Addon] >= limit {
+		found = true
+		return
+	}
+
+	return
+}
+
+//
+// add a (started) task.
+func (r *Concurrency) add(task *model.Task) {
+	r.Total++
+	r.Addon[task.Addon]++
+	if task.Isolated {
+		r.Isolated++
+	}
+}
+
 //
 // Task is an runtime task.
 type Task struct {
@@ -145,17 +691,26 @@ type Task struct {
 	*model.Task
 	// k8s client.
 	client client.Client
+	// DB
+	db *gorm.DB
 	// addon
 	addon *crd.Addon
+	// proxy env.
+	proxy map[string]string
+	// Attempt lost (job not found).
+	lost *model.TaskAttempt
 }
 
 //
 // Run the specified task.
+// On error, the task is marked failed (terminated).
 func (r *Task) Run() (err error) {
 	defer func() {
 		if err != nil {
+			mark := time.Now()
 			r.Error = err.Error()
 			r.Status = Failed
+			r.Terminated = &mark
 		}
 	}()
 	r.addon, err = r.findAddon(r.Addon)
@@ -163,7 +718,15 @@ func (r *Task) Run() (err error) {
 		return
 	}
 	r.Image = r.addon.Spec.Image
-	secret := r.secret()
+	proxy := ProxyEnv{DB: r.db}
+	r.proxy, err = proxy.Build()
+	if err != nil {
+		return
+	}
+	secret, err := r.secret()
+	if err != nil {
+		return
+	}
 	err = r.client.Create(context.TODO(), &secret)
 	if err != nil {
 		return
@@ -173,8 +736,14 @@ func (r *Task) Run() (err error) {
 	if err != nil {
 		return
 	}
+	err = r.own(&secret, &job)
+	if err != nil {
+		log.Error(err, "Set secret owner failed.", "job", job.Name)
+		err = nil
+	}
 	mark := time.Now()
 	r.Started = &mark
+	r.RetryAfter = nil
 	r.Status = Running
 	r.Job = path.Join(
 		job.Namespace,
@@ -182,8 +751,28 @@ func (r *Task) Run() (err error) {
 	return
 }
 
+//
+// own sets the job as the owner of the secret.
+// The secret is deleted (by k8s) with the job.
+func (r *Task) own(secret *core.Secret, job *batch.Job) (err error) {
+	secret.OwnerReferences = append(
+		secret.OwnerReferences,
+		meta.OwnerReference{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+			Name:       job.Name,
+			UID:        job.UID,
+		})
+	err = r.client.Update(context.TODO(), secret)
+	return
+}
+
 //
 // Reflect finds the associated job and updates the task status.
+// The task is re-run when the job is not found and the lost
+// attempt is recorded. While the job is active, the status
+// reflects the phase of the pod. The k8s events for the job
+// and pods are recorded.
 func (r *Task) Reflect() (err error) {
 	job := &batch.Job{}
 	err = r.client.Get(
@@ -195,22 +784,245 @@ func (r *Task) Reflect() (err error) {
 		job)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			mark := time.Now()
+			r.lost = &model.TaskAttempt{
+				TaskID:     r.ID,
+				Job:        r.Job,
+				Started:    r.Started,
+				Terminated: &mark,
+				Status:     Lost,
+				Reason:     "job not found: task re-run.",
+			}
+			log.Info("Job not found, task re-run.", "task", r.ID, "job", r.Job)
 			err = r.Run()
 		}
 		return
 	}
+	inspected := r.inspect(job)
+	var pods []core.Pod
+	if inspected {
+		pods, err = r.pods()
+		if err != nil {
+			return
+		}
+		r.events(pods)
+	}
 	mark := time.Now()
 	status := job.Status
 	for _, cnd := range status.Conditions {
 		if cnd.Type == batch.JobFailed {
+			if cnd.Reason == "DeadlineExceeded" {
+				r.Status = TimedOut
+				r.Terminated = &mark
+				r.Error = fmt.Sprintf(
+					"job timed out: not completed within: %ds.",
+					r.deadline(job))
+				return
+			}
 			r.Status = Failed
 			r.Terminated = &mark
 			r.Error = "job failed."
+			if cnd.Reason != "" {
+				r.Error = fmt.Sprintf(
+					"job failed: %s: %s",
+					cnd.Reason,
+					cnd.Message)
+			}
 			return
 		}
 		if status.Succeeded > 0 {
 			r.Status = Succeeded
 			r.Terminated = &mark
+			return
+		}
+	}
+	if inspected {
+		r.Status = r.phase(pods)
+	}
+
+	return
+}
+
+//
+// inspect returns true when the pods and events need to
+// be inspected. A running task is inspected only when the
+// job has failed pods or has terminated.
+func (r *Task) inspect(job *batch.Job) (inspect bool) {
+	inspect = r.Status != Running ||
+		job.Status.Active == 0 ||
+		job.Status.Failed > 0 ||
+		len(job.Status.Conditions) > 0
+	return
+}
+
+//
+// pods returns the pods created for the job.
+func (r *Task) pods() (pods []core.Pod, err error) {
+	collector := Log{Client: r.client}
+	pods, err = collector.Pods(r.Job)
+	return
+}
+
+//
+// events records the k8s events for the job and pods.
+// Recorded events are preserved when none are found.
+func (r *Task) events(pods []core.Pod) {
+	finder := Events{Client: r.client}
+	list, err := finder.List(r.Job, pods)
+	if err != nil {
+		log.Error(err, "List events failed.", "job", r.Job)
+		return
+	}
+	if len(list) > 0 {
+		r.Events, _ = json.Marshal(list)
+	}
+}
+
+//
+// phase returns the (active) status based on the phase
+// of the most recent pod.
+func (r *Task) phase(pods []core.Pod) (status string) {
+	status = Scheduling
+	var pod *core.Pod
+	for i := range pods {
+		p := &pods[i]
+		if pod == nil || pod.CreationTimestamp.Before(&p.CreationTimestamp) {
+			pod = p
+		}
+	}
+	if pod == nil {
+		return
+	}
+	switch pod.Status.Phase {
+	case core.PodPending:
+		statuses := append(
+			pod.Status.InitContainerStatuses,
+			pod.Status.ContainerStatuses...)
+		for _, container := range statuses {
+			waiting := container.State.Waiting
+			if waiting == nil {
+				continue
+			}
+			switch waiting.Reason {
+			case "ErrImagePull",
+				"ImagePullBackOff",
+				"InvalidImageName":
+				status = ImagePullFailed
+				return
+			}
+		}
+	case core.PodFailed:
+		status = Running
+		if pod.Status.Reason == "Evicted" {
+			status = Evicted
+		}
+	default:
+		status = Running
+	}
+
+	return
+}
+
+//
+// deadline returns the job active deadline (seconds).
+func (r *Task) deadline(job *batch.Job) (seconds int64) {
+	if job.Spec.ActiveDeadlineSeconds != nil {
+		seconds = *job.Spec.ActiveDeadlineSeconds
+	}
+	return
+}
+
+//
+// timeout returns the task timeout (seconds).
+// The task timeout has precedence over the addon default.
+func (r *Task) timeout() (seconds int) {
+	seconds = r.Timeout
+	if seconds == 0 && r.addon != nil {
+		seconds = r.addon.Spec.Timeout
+	}
+	return
+}
+
+//
+// Retry resets the task to be retried after the backoff.
+func (r *Task) Retry(backoff time.Duration) {
+	mark := time.Now().Add(backoff)
+	r.RetryAfter = &mark
+	r.Status = Pending
+	r.Started = nil
+	r.Terminated = nil
+	r.Error = ""
+	r.Job = ""
+}
+
+//
+// retryPolicy returns the max attempts and the initial backoff.
+// The task policy has precedence over the addon policy.
+func (r *Task) retryPolicy() (maxAttempts int, backoff time.Duration) {
+	maxAttempts = r.MaxAttempts
+	seconds := r.Backoff
+	if maxAttempts == 0 {
+		addon, err := r.findAddon(r.Addon)
+		if err == nil && addon.Spec.Retry != nil {
+			maxAttempts = addon.Spec.Retry.MaxAttempts
+			seconds = addon.Spec.Retry.Backoff
+		}
+	}
+	backoff = time.Second * time.Duration(seconds)
+	if backoff == 0 {
+		backoff = DefaultBackoff
+	}
+
+	return
+}
+
+//
+// Cancel the task.
+// Deletes the job and secret and marks the task canceled.
+func (r *Task) Cancel() (err error) {
+	err = r.Delete()
+	if err != nil {
+		return
+	}
+	mark := time.Now()
+	r.Status = Canceled
+	r.Terminated = &mark
+	return
+}
+
+//
+// Delete the associated job and secret.
+func (r *Task) Delete() (err error) {
+	if r.Job != "" {
+		job := &batch.Job{}
+		job.Namespace = path.Dir(r.Job)
+		job.Name = path.Base(r.Job)
+		err = r.client.Delete(
+			context.TODO(),
+			job,
+			client.PropagationPolicy(meta.DeletePropagationBackground))
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return
+			}
+			err = nil
+		}
+	}
+	list := &core.SecretList{}
+	err = r.client.List(
+		context.TODO(),
+		client.InNamespace(Settings.Hub.Namespace).MatchingLabels(r.labels()),
+		list)
+	if err != nil {
+		return
+	}
+	for i := range list.Items {
+		err = r.client.Delete(context.TODO(), &list.Items[i])
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return
+			}
+			err = nil
 		}
 	}
 
@@ -251,18 +1063,27 @@ func (r *Task) job(secret *core.Secret) (job batch.Job) {
 			Labels:       r.labels(),
 		},
 	}
+	timeout := r.timeout()
+	if timeout > 0 {
+		deadline := int64(timeout)
+		job.Spec.ActiveDeadlineSeconds = &deadline
+	}
 
 	return
 }
 
 //
 // template builds a Job template.
+// The bucket volume is mounted only for (fs) bucket storage.
 func (r *Task) template(secret *core.Secret) (template core.PodTemplateSpec) {
 	template = core.PodTemplateSpec{
+		ObjectMeta: meta.ObjectMeta{
+			Labels: r.labels(),
+		},
 		Spec: core.PodSpec{
 			RestartPolicy: core.RestartPolicyNever,
 			Containers: []core.Container{
-				r.container(),
+				r.container(secret),
 			},
 			Volumes: []core.Volume{
 				{
@@ -276,20 +1097,30 @@ func (r *Task) template(secret *core.Secret) (template core.PodTemplateSpec) {
 					VolumeSource: core.VolumeSource{
 						Secret: &core.SecretVolumeSource{
 							SecretName: secret.Name,
-						},
-					},
-				},
-				{
-					Name: "bucket",
-					VolumeSource: core.VolumeSource{
-						PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{
-							ClaimName: Settings.Hub.Bucket.PVC,
+							Items: []core.KeyToPath{
+								{
+									Key:  path.Base(Settings.Addon.Path.Secret),
+									Path: path.Base(Settings.Addon.Path.Secret),
+								},
+							},
 						},
 					},
 				},
 			},
 		},
 	}
+	if Settings.Hub.Bucket.Storage == settings.BucketStorageFS {
+		template.Spec.Volumes = append(
+			template.Spec.Volumes,
+			core.Volume{
+				Name: "bucket",
+				VolumeSource: core.VolumeSource{
+					PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{
+						ClaimName: Settings.Hub.Bucket.PVC,
+					},
+				},
+			})
+	}
 	mounts := r.addon.Spec.Mounts
 	for _, mnt := range mounts {
 		template.Spec.Volumes = append(
@@ -303,22 +1134,26 @@ func (r *Task) template(secret *core.Secret) (template core.PodTemplateSpec) {
 				},
 			})
 	}
+	spec := &r.addon.Spec
+	template.Spec.ServiceAccountName = spec.ServiceAccountName
+	template.Spec.ImagePullSecrets = spec.ImagePullSecrets
+	template.Spec.NodeSelector = spec.NodeSelector
+	template.Spec.Tolerations = spec.Tolerations
 
 	return
 }
 
 //
 // container builds the job container.
-func (r *Task) container() (container core.Container) {
+// Env defined by the addon cannot replace the env
+// set by the hub. The proxy env is referenced in the
+// secret because it may contain credentials.
+func (r *Task) container(secret *core.Secret) (container core.Container) {
 	container = core.Container{
 		Name:       "main",
 		Image:      r.Image,
 		WorkingDir: Settings.Addon.Path.WorkingDir,
 		Env: []core.EnvVar{
-			{
-				Name:  settings.EnvBucketPath,
-				Value: Settings.Hub.Bucket.Path,
-			},
 			{
 				Name:  settings.EnvHubBaseURL,
 				Value: Settings.Addon.Hub.URL,
@@ -341,11 +1176,55 @@ func (r *Task) container() (container core.Container) {
 				Name:      "secret",
 				MountPath: path.Dir(Settings.Addon.Path.Secret),
 			},
-			{
+		},
+	}
+	if Settings.Hub.Bucket.Storage == settings.BucketStorageFS {
+		container.Env = append(
+			container.Env,
+			core.EnvVar{
+				Name:  settings.EnvBucketPath,
+				Value: Settings.Hub.Bucket.Path,
+			})
+		container.VolumeMounts = append(
+			container.VolumeMounts,
+			core.VolumeMount{
 				Name:      "bucket",
 				MountPath: Settings.Hub.Bucket.Path,
-			},
-		},
+			})
+	}
+	names := []string{}
+	for name := range r.proxy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		container.Env = append(
+			container.Env,
+			core.EnvVar{
+				Name: name,
+				ValueFrom: &core.EnvVarSource{
+					SecretKeyRef: &core.SecretKeySelector{
+						LocalObjectReference: core.LocalObjectReference{
+							Name: secret.Name,
+						},
+						Key: name,
+					},
+				},
+			})
+	}
+	spec := &r.addon.Spec
+	container.Resources = spec.Resources
+	container.ImagePullPolicy = spec.ImagePullPolicy
+	container.SecurityContext = spec.SecurityContext
+	reserved := make(map[string]bool)
+	for _, env := range container.Env {
+		reserved[env.Name] = true
+	}
+	for _, env := range spec.Env {
+		if reserved[env.Name] {
+			continue
+		}
+		container.Env = append(container.Env, env)
 	}
 
 	return
@@ -353,10 +1232,17 @@ func (r *Task) container() (container core.Container) {
 
 //
 // secret builds the job secret.
-func (r *Task) secret() (secret core.Secret) {
+// A token is minted for the task. Only the hash
+// is stored (on the task).
+func (r *Task) secret() (secret core.Secret, err error) {
+	token, hash, err := NewToken()
+	if err != nil {
+		return
+	}
+	r.Token = hash
 	data := Secret{}
+	data.Hub.Token = token
 	data.Hub.Task = r.Task.ID
-	data.Hub.Encryption.Passphrase = Settings.Encryption.Passphrase
 	data.Addon = r.Task.Data
 	encoded, _ := json.Marshal(data)
 	secret = core.Secret{
@@ -369,6 +1255,9 @@ func (r *Task) secret() (secret core.Secret) {
 			path.Base(Settings.Addon.Path.Secret): encoded,
 		},
 	}
+	for name, value := range r.proxy {
+		secret.Data[name] = []byte(value)
+	}
 
 	return
 }
@@ -377,19 +1266,25 @@ func (r *Task) secret() (secret core.Secret) {
 // labels builds k8s labels.
 func (r *Task) labels() map[string]string {
 	return map[string]string{
-		"Task": strconv.Itoa(int(r.ID)),
+		TaskLabel: strconv.Itoa(int(r.ID)),
 	}
 }
 
+//
+// Input references an output reported by another task.
+type Input struct {
+	// Task ID.
+	Task uint `json:"task"`
+	// Output key.
+	Key string `json:"key"`
+}
+
 //
 // Secret payload.
 type Secret struct {
 	Hub struct {
-		Token      string
-		Task       uint
-		Encryption struct {
-			Passphrase string
-		}
+		Token string
+		Task  uint
 	}
 	Addon interface{}
 }
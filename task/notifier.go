@@ -0,0 +1,88 @@
+package task
+
+import (
+	"sync"
+)
+
+//
+// Notification kinds.
+const (
+	Created = "created"
+	Updated = "updated"
+	Report  = "report"
+)
+
+//
+// Notifier delivers task notifications (in-process).
+var Notifier = &Bus{
+	subscribers: make(map[*Subscriber]bool),
+}
+
+//
+// Notification of a task change.
+type Notification struct {
+	// Kind (created|updated|report).
+	Kind string `json:"kind"`
+	// Task ID.
+	Task uint `json:"task"`
+	// Task status.
+	Status string `json:"status"`
+}
+
+//
+// Subscriber receives notifications.
+type Subscriber struct {
+	// Task ID (0=all).
+	Task uint
+	// Notifications delivered.
+	Channel chan Notification
+}
+
+//
+// Bus delivers notifications to subscribers.
+type Bus struct {
+	subscribers map[*Subscriber]bool
+	mutex       sync.RWMutex
+}
+
+//
+// Subscribe to notifications for the task (0=all).
+func (r *Bus) Subscribe(task uint) (s *Subscriber) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	s = &Subscriber{
+		Task:    task,
+		Channel: make(chan Notification, 100),
+	}
+	r.subscribers[s] = true
+	return
+}
+
+//
+// Unsubscribe ends the subscription.
+func (r *Bus) Unsubscribe(s *Subscriber) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.subscribers[s] {
+		delete(r.subscribers, s)
+		close(s.Channel)
+	}
+}
+
+//
+// Publish a notification.
+// Subscribers not keeping up miss notifications rather
+// than blocking the publisher.
+func (r *Bus) Publish(n Notification) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for s := range r.subscribers {
+		if s.Task != 0 && s.Task != n.Task {
+			continue
+		}
+		select {
+		case s.Channel <- n:
+		default:
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package task
+
+import (
+	"encoding/json"
+	"github.com/konveyor/tackle-hub/model"
+	"gorm.io/gorm"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+//
+// Proxy env.
+const (
+	EnvHttpProxy  = "HTTP_PROXY"
+	EnvHttpsProxy = "HTTPS_PROXY"
+	EnvNoProxy    = "NO_PROXY"
+)
+
+//
+// ProxyEnv builds the proxy env for addons.
+type ProxyEnv struct {
+	// DB
+	DB *gorm.DB
+	// HubURL used by addons.
+	// Default: Settings.Addon.Hub.URL.
+	HubURL string
+}
+
+//
+// Build the env (name => value) using the proxies and
+// the (decrypted) credentials of the linked identities.
+// Both upper and lower case names are included because
+// tools do not agree on which is used. The hub and cluster
+// (service) hosts are always excluded so addons reach the
+// hub directly. Proxies with an invalid URL are skipped.
+func (r *ProxyEnv) Build() (env map[string]string, err error) {
+	env = make(map[string]string)
+	if r.DB == nil {
+		return
+	}
+	list := []model.Proxy{}
+	result := r.DB.Find(&list)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	excluded := []string{}
+	for i := range list {
+		proxy := &list[i]
+		var name string
+		switch proxy.Kind {
+		case "http":
+			name = EnvHttpProxy
+		case "https":
+			name = EnvHttpsProxy
+		default:
+			continue
+		}
+		u, urlErr := r.url(proxy)
+		if urlErr != nil {
+			log.Error(
+				urlErr,
+				"Proxy URL not valid, skipped.",
+				"proxy",
+				proxy.ID)
+			continue
+		}
+		env[name] = u
+		hosts := []string{}
+		_ = json.Unmarshal(proxy.Excluded, &hosts)
+		excluded = append(excluded, hosts...)
+	}
+	if len(env) == 0 {
+		return
+	}
+	excluded = append(r.cluster(), excluded...)
+	env[EnvNoProxy] = strings.Join(excluded, ",")
+	for name, value := range env {
+		env[strings.ToLower(name)] = value
+	}
+
+	return
+}
+
+//
+// cluster returns the hosts that are always excluded.
+// The hub host and the cluster service domains.
+func (r *ProxyEnv) cluster() (hosts []string) {
+	hubURL := r.HubURL
+	if hubURL == "" {
+		hubURL = Settings.Addon.Hub.URL
+	}
+	u, err := url.Parse(hubURL)
+	if err == nil && u.Hostname() != "" {
+		hosts = append(hosts, u.Hostname())
+	}
+	hosts = append(
+		hosts,
+		".svc",
+		".cluster.local")
+	return
+}
+
+//
+// url builds the proxy URL.
+// The credentials are omitted (logged) when the identity
+// cannot be found or decrypted.
+func (r *ProxyEnv) url(proxy *model.Proxy) (s string, err error) {
+	u := &url.URL{
+		Scheme: "http",
+		Host:   proxy.Host,
+	}
+	if strings.Contains(proxy.Host, "://") {
+		u, err = url.Parse(proxy.Host)
+		if err != nil {
+			return
+		}
+	}
+	if proxy.Port > 0 {
+		u.Host = net.JoinHostPort(
+			u.Hostname(),
+			strconv.Itoa(proxy.Port))
+	}
+	if proxy.IdentityID > 0 {
+		u.User = r.credentials(proxy)
+	}
+	s = u.String()
+	return
+}
+
+//
+// credentials returns the (decrypted) user and password
+// of the proxy identity.
+func (r *ProxyEnv) credentials(proxy *model.Proxy) (user *url.Userinfo) {
+	identity := &model.Identity{}
+	result := r.DB.First(identity, proxy.IdentityID)
+	if result.Error != nil {
+		log.Error(
+			result.Error,
+			"Proxy identity not found, credentials omitted.",
+			"proxy",
+			proxy.ID,
+			"identity",
+			proxy.IdentityID)
+		return
+	}
+	err := identity.Decrypt(Settings.Encryption.Passphrase)
+	if err != nil {
+		log.Error(
+			err,
+			"Proxy identity not decrypted, credentials omitted.",
+			"proxy",
+			proxy.ID,
+			"identity",
+			proxy.IdentityID)
+		return
+	}
+	if identity.User != "" {
+		user = url.UserPassword(
+			identity.User,
+			identity.Password)
+	}
+	return
+}
@@ -0,0 +1,167 @@
+package task
+
+import (
+	"context"
+	"github.com/konveyor/tackle-hub/model"
+	"gorm.io/gorm"
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"path"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"time"
+)
+
+//
+// Grace period for resources created by tasks being started.
+const (
+	Grace = time.Minute
+)
+
+//
+// Reaper deletes orphaned Jobs and Secrets.
+// Resources (labeled with Task) are orphaned when:
+//   - the task has been deleted.
+//   - the task terminated beyond the retention period.
+//   - the job has been superseded (task re-run).
+type Reaper struct {
+	// DB
+	DB *gorm.DB
+	// k8s client.
+	Client client.Client
+}
+
+//
+// Run the reaper.
+func (m *Reaper) Run(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				_ = m.reapJobs()
+				_ = m.reapSecrets()
+				time.Sleep(time.Minute)
+			}
+		}
+	}()
+}
+
+//
+// reapJobs deletes orphaned jobs.
+func (m *Reaper) reapJobs() (err error) {
+	list := &batch.JobList{}
+	err = m.list(list)
+	if err != nil {
+		return
+	}
+	for i := range list.Items {
+		job := &list.Items[i]
+		task, found, fErr := m.find(job)
+		if fErr != nil {
+			continue
+		}
+		ref := path.Join(job.Namespace, job.Name)
+		superseded := found &&
+			task.Job != ref &&
+			time.Since(job.CreationTimestamp.Time) > Grace
+		if !found || superseded || m.expired(task) {
+			m.delete(job)
+		}
+	}
+
+	return
+}
+
+//
+// reapSecrets deletes orphaned secrets.
+// Secrets owned by a job are deleted (by k8s) with the job.
+func (m *Reaper) reapSecrets() (err error) {
+	list := &core.SecretList{}
+	err = m.list(list)
+	if err != nil {
+		return
+	}
+	for i := range list.Items {
+		secret := &list.Items[i]
+		if len(secret.OwnerReferences) > 0 {
+			continue
+		}
+		task, found, fErr := m.find(secret)
+		if fErr != nil {
+			continue
+		}
+		if !found || m.expired(task) {
+			m.delete(secret)
+		}
+	}
+
+	return
+}
+
+//
+// list resources labeled with Task.
+func (m *Reaper) list(list runtime.Object) (err error) {
+	options := client.InNamespace(Settings.Hub.Namespace)
+	err = options.SetLabelSelector(TaskLabel)
+	if err != nil {
+		return
+	}
+	err = m.Client.List(context.TODO(), options, list)
+	return
+}
+
+//
+// find the task referenced by the Task label.
+func (m *Reaper) find(object meta.Object) (task *model.Task, found bool, err error) {
+	id, err := strconv.Atoi(object.GetLabels()[TaskLabel])
+	if err != nil {
+		return
+	}
+	task = &model.Task{}
+	result := m.DB.Limit(1).Find(task, id)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	found = result.RowsAffected > 0
+	return
+}
+
+//
+// expired returns true when the task terminated beyond
+// the retention period.
+func (m *Reaper) expired(task *model.Task) bool {
+	if task.Terminated == nil {
+		return false
+	}
+	retention := time.Duration(Settings.Hub.Task.Retention) * time.Second
+	return time.Since(*task.Terminated) > retention
+}
+
+//
+// delete the resource.
+func (m *Reaper) delete(object runtime.Object) {
+	err := m.Client.Delete(
+		context.TODO(),
+		object,
+		client.PropagationPolicy(meta.DeletePropagationBackground))
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "Delete orphan failed.")
+		}
+		return
+	}
+	if object, cast := object.(meta.Object); cast {
+		log.Info(
+			"Orphan deleted.",
+			"name",
+			path.Join(object.GetNamespace(), object.GetName()),
+			"task",
+			object.GetLabels()[TaskLabel])
+	}
+}
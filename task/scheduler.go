@@ -0,0 +1,227 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/konveyor/tackle-hub/cron"
+	"github.com/konveyor/tackle-hub/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+//
+// Scheduler creates tasks as scheduled.
+type Scheduler struct {
+	// DB
+	DB *gorm.DB
+}
+
+//
+// Run the scheduler.
+func (m *Scheduler) Run(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				time.Sleep(time.Second)
+				_ = m.schedule()
+			}
+		}
+	}()
+}
+
+//
+// schedule creates tasks for schedules that are due.
+// A run is skipped while tasks created by the previous
+// run have not terminated.
+func (m *Scheduler) schedule() (err error) {
+	list := []model.Schedule{}
+	result := m.DB.Find(&list)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	now := time.Now()
+	for i := range list {
+		schedule := &list[i]
+		parsed, pErr := cron.Parse(schedule.Cron)
+		if pErr != nil {
+			continue
+		}
+		next := parsed.Next(now)
+		if next.IsZero() {
+			continue
+		}
+		if schedule.NextRun != nil && now.Before(*schedule.NextRun) {
+			continue
+		}
+		if schedule.NextRun != nil {
+			overlapping, oErr := m.overlapping(schedule)
+			if oErr != nil {
+				continue
+			}
+			if overlapping {
+				log.Info(
+					"Schedule run skipped, previous run not terminated.",
+					"name",
+					schedule.Name)
+			} else {
+				cErr := m.create(schedule)
+				if cErr != nil {
+					log.Error(
+						cErr,
+						"Schedule run failed.",
+						"name",
+						schedule.Name)
+					continue
+				}
+				mark := now
+				schedule.LastRun = &mark
+			}
+		}
+		schedule.NextRun = &next
+		db := m.DB.Model(schedule)
+		db = db.Select("LastRun", "NextRun")
+		_ = db.Updates(schedule)
+	}
+
+	return
+}
+
+//
+// overlapping returns true when tasks created by the
+// schedule have not terminated.
+func (m *Scheduler) overlapping(schedule *model.Schedule) (found bool, err error) {
+	var count int64
+	db := m.DB.Model(&model.Task{})
+	db = db.Where("scheduleid", schedule.ID)
+	db = db.Where(
+		"status IN ?",
+		append(
+			[]string{
+				Pending,
+				Postponed,
+			},
+			Active...))
+	result := db.Count(&count)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	found = count > 0
+	return
+}
+
+//
+// create the tasks for a scheduled run.
+// When the schedule has a selector, a task is created for
+// each selected application. The application ID is set in
+// the task data.
+func (m *Scheduler) create(schedule *model.Schedule) (err error) {
+	tasks := []model.Task{}
+	selector := Selector{}
+	_ = json.Unmarshal(schedule.Selector, &selector)
+	if selector.Empty() {
+		tasks = append(tasks, m.task(schedule, nil))
+	} else {
+		var ids []uint
+		ids, err = selector.Applications(m.DB)
+		if err != nil {
+			return
+		}
+		for _, id := range ids {
+			tasks = append(tasks, m.task(schedule, &id))
+		}
+	}
+	if len(tasks) == 0 {
+		return
+	}
+	result := m.DB.Create(&tasks)
+	err = result.Error
+	if err != nil {
+		return
+	}
+	for i := range tasks {
+		Notifier.Publish(
+			Notification{
+				Kind:   Created,
+				Task:   tasks[i].ID,
+				Status: tasks[i].Status,
+			})
+	}
+	return
+}
+
+//
+// task builds a task for the schedule.
+func (m *Scheduler) task(schedule *model.Schedule, appId *uint) (task model.Task) {
+	task = model.Task{
+		Name:       schedule.Addon,
+		Addon:      schedule.Addon,
+		ScheduleID: &schedule.ID,
+		Data:       schedule.Data,
+	}
+	if appId != nil {
+		data := make(map[string]interface{})
+		_ = json.Unmarshal(schedule.Data, &data)
+		data["application"] = *appId
+		task.Data, _ = json.Marshal(data)
+		id := *appId
+		task.ApplicationID = &id
+	}
+
+	return
+}
+
+//
+// Selector selects applications.
+// Applications are selected when having all of the tags
+// and when they belong to the business service.
+type Selector struct {
+	// Tag IDs.
+	Tags []uint `json:"tags,omitempty"`
+	// BusinessService ID.
+	BusinessService uint `json:"businessService,omitempty"`
+}
+
+//
+// Empty returns true when no criteria are specified.
+func (r *Selector) Empty() bool {
+	return len(r.Tags) == 0 && r.BusinessService == 0
+}
+
+//
+// Applications returns the IDs of the selected applications.
+func (r *Selector) Applications(db *gorm.DB) (ids []uint, err error) {
+	list := []model.Application{}
+	db = db.Preload("Tags")
+	if r.BusinessService > 0 {
+		db = db.Where("businessserviceid", r.BusinessService)
+	}
+	result := db.Find(&list)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	for i := range list {
+		application := &list[i]
+		tags := make(map[uint]bool)
+		for _, tag := range application.Tags {
+			tags[tag.ID] = true
+		}
+		matched := true
+		for _, id := range r.Tags {
+			if !tags[id] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			ids = append(ids, application.ID)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,82 @@
+package task
+
+import (
+	"github.com/konveyor/tackle-hub/model"
+	"github.com/onsi/gomega"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	"testing"
+	"time"
+)
+
+func TestScheduleOverlap(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db := testDB(t)
+	scheduler := &Scheduler{DB: db}
+	schedule := &model.Schedule{
+		Name:  "test",
+		Cron:  "* * * * *",
+		Addon: "test",
+	}
+	g.Expect(db.Create(schedule).Error).To(gomega.BeNil())
+	tasks := func() (count int64) {
+		db.Model(&model.Task{}).Where("scheduleid", schedule.ID).Count(&count)
+		return
+	}
+	due := func() {
+		past := time.Now().Add(-time.Minute)
+		db.Model(schedule).Update("NextRun", &past)
+	}
+	//
+	// First pass: next run determined.
+	g.Expect(scheduler.schedule()).To(gomega.BeNil())
+	g.Expect(tasks()).To(gomega.Equal(int64(0)))
+	g.Expect(db.First(schedule).Error).To(gomega.BeNil())
+	g.Expect(schedule.NextRun).ToNot(gomega.BeNil())
+	g.Expect(schedule.NextRun.After(time.Now())).To(gomega.BeTrue())
+	//
+	// Due: task created.
+	due()
+	g.Expect(scheduler.schedule()).To(gomega.BeNil())
+	g.Expect(tasks()).To(gomega.Equal(int64(1)))
+	g.Expect(db.First(schedule).Error).To(gomega.BeNil())
+	g.Expect(schedule.LastRun).ToNot(gomega.BeNil())
+	lastRun := *schedule.LastRun
+	//
+	// Due while the task is running: skipped.
+	db.Model(&model.Task{}).Where("scheduleid", schedule.ID).Update("Status", Running)
+	due()
+	g.Expect(scheduler.schedule()).To(gomega.BeNil())
+	g.Expect(tasks()).To(gomega.Equal(int64(1)))
+	g.Expect(db.First(schedule).Error).To(gomega.BeNil())
+	g.Expect(schedule.LastRun.Equal(lastRun)).To(gomega.BeTrue())
+	g.Expect(schedule.NextRun.After(time.Now())).To(gomega.BeTrue())
+	//
+	// Due after the task terminated: task created.
+	db.Model(&model.Task{}).Where("scheduleid", schedule.ID).Update("Status", Failed)
+	due()
+	g.Expect(scheduler.schedule()).To(gomega.BeNil())
+	g.Expect(tasks()).To(gomega.Equal(int64(2)))
+}
+
+//
+// testDB returns a new (migrated) DB.
+func testDB(t *testing.T) (db *gorm.DB) {
+	g := gomega.NewGomegaWithT(t)
+	db, err := gorm.Open(
+		sqlite.Open(t.TempDir()+"/test.db"),
+		&gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+			NamingStrategy: &schema.NamingStrategy{
+				SingularTable: true,
+				NoLowerCase:   true,
+			},
+		})
+	g.Expect(err).To(gomega.BeNil())
+	err = db.AutoMigrate(model.All()...)
+	g.Expect(err).To(gomega.BeNil())
+	return
+}
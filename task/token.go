@@ -0,0 +1,29 @@
+package task
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+//
+// NewToken returns a new (random) task token and
+// the hash to be stored.
+func NewToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	_, err = rand.Read(b)
+	if err != nil {
+		return
+	}
+	token = hex.EncodeToString(b)
+	hash = HashToken(token)
+	return
+}
+
+//
+// HashToken returns the hash of the token.
+func HashToken(token string) (hash string) {
+	sum := sha256.Sum256([]byte(token))
+	hash = hex.EncodeToString(sum[:])
+	return
+}